@@ -4,10 +4,9 @@ import (
 	"path/filepath"
 	"strconv"
 
-	"github.com/bogem/id3v2/v2"
 	"github.com/spf13/cobra"
 	"github.com/streambinder/spotitube/downloader"
-	"github.com/streambinder/spotitube/entity/id3"
+	"github.com/streambinder/spotitube/entity/tags"
 	"github.com/streambinder/spotitube/lyrics"
 	"github.com/streambinder/spotitube/processor"
 	"github.com/streambinder/spotitube/spotify"
@@ -31,7 +30,7 @@ func cmdAttach() *cobra.Command {
 				rename = sys.ErrWrap(false)(cmd.Flags().GetBool("rename"))
 			)
 
-			localTrack, err := id3.Open(path, id3v2.Options{Parse: false})
+			localTrack, err := tags.Open(path, tags.ParseNone)
 			if err != nil {
 				return err
 			}
@@ -79,7 +78,9 @@ func cmdAttach() *cobra.Command {
 			}
 
 			if rename {
-				return sys.FileMoveOrCopy(path, filepath.Join(filepath.Dir(path), spotifyTrack.Path().Final()))
+				// the local track keeps its own format, whatever the configured one
+				target := sys.FileBaseStem(spotifyTrack.Path().Final()) + filepath.Ext(path)
+				return sys.FileMoveOrCopy(path, filepath.Join(filepath.Dir(path), target))
 			}
 			return nil
 		},
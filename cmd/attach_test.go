@@ -137,3 +137,29 @@ func TestCmdAttachRenameFailure(t *testing.T) {
 	// testing
 	assert.EqualError(t, sys.ErrOnly(testExecute(cmdAttach(), "--rename", "/path", "spotifyid")), "ko")
 }
+
+func TestCmdAttachRenameExtension(t *testing.T) {
+	_track := &entity.Track{ID: "TestCmdAttachRenameExtension", Title: "Title", Artists: []string{"Artist"}}
+	assert.Nil(t, entity.SetFormat("flac"))
+	defer entity.SetFormat("mp3")
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(id3v2.Open).Return(id3v2.NewEmptyTag(), nil).Build()
+	mockey.Mock(spotify.Authenticate).Return(&spotify.Client{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Track")).Return(_track, nil).Build()
+	mockey.Mock(lyrics.Search).Return("", nil).Build()
+	mockey.Mock(downloader.Download).To(func(_, _ string, _ processor.Processor, ch ...chan []byte) error {
+		ch[0] <- []byte{}
+		return nil
+	}).Build()
+	mockey.Mock(mockey.GetMethod(&id3v2.Tag{}, "Save")).Return(nil).Build()
+	mockey.Mock(sys.FileMoveOrCopy).To(func(source, target string, _ ...bool) error {
+		assert.Equal(t, "/folder/track.mp3", source)
+		assert.Equal(t, "/folder/Artist - Title.mp3", target)
+		return nil
+	}).Build()
+
+	// testing
+	assert.Nil(t, sys.ErrOnly(testExecute(cmdAttach(), "--rename", "/folder/track.mp3", "spotifyid")))
+}
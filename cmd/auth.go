@@ -2,10 +2,15 @@ package cmd
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"io/fs"
 	"os"
+	"slices"
+	"text/tabwriter"
 
 	"github.com/spf13/cobra"
+	"github.com/streambinder/spotitube/config"
 	"github.com/streambinder/spotitube/spotify"
 	"github.com/streambinder/spotitube/sys"
 )
@@ -20,15 +25,87 @@ func cmdAuth() *cobra.Command {
 		Short:        "Establish a Spotify session for future uses",
 		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, _ []string) error {
+			var (
+				headless     = sys.ErrWrap(false)(cmd.Flags().GetBool("headless"))
+				input        = sys.ErrWrap("")(cmd.Flags().GetString("input"))
+				port         = sys.ErrWrap(0)(cmd.Flags().GetInt("port"))
+				redirectHost = sys.ErrWrap("")(cmd.Flags().GetString("redirect-host"))
+			)
+
+			if sys.ErrWrap(false)(cmd.Flags().GetBool("list")) {
+				return authList(cmd)
+			}
+
 			if sys.ErrWrap(false)(cmd.Flags().GetBool("logout")) {
-				if err := os.Remove(sys.CacheFile(spotify.TokenBasename)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				if err := os.Remove(spotify.TokenPath()); err != nil && !errors.Is(err, fs.ErrNotExist) {
 					return err
 				}
 			}
 
-			return sys.ErrOnly(spotify.Authenticate(spotify.BrowserProcessor))
+			// flags win over the configured callback, if any
+			spotify.SetRedirect(redirectHost, port)
+			if !headless {
+				return sys.ErrOnly(spotify.Authenticate(spotify.BrowserProcessor))
+			}
+
+			var reader io.Reader = os.Stdin
+			if len(input) > 0 {
+				file, err := os.Open(input)
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+				reader = file
+			}
+			return sys.ErrOnly(spotify.AuthenticateHeadless(authHeadlessProcessor, reader))
 		},
 	}
 	cmd.Flags().BoolP("logout", "l", false, "Logout before starting authentication process")
+	cmd.Flags().Bool("list", false, "List profiles along with their authentication status")
+	cmd.Flags().Bool("headless", false, "Print the authorization URL and read the URL it redirects to (or its code), instead of serving the callback")
+	cmd.Flags().String("input", "", "Read the redirected URL (or its code) from the given file, instead of standard input (with --headless)")
+	cmd.Flags().Int("port", 0, "Port of the authorization callback (defaults to the configured one, or 65535)")
+	cmd.Flags().String("redirect-host", "", "Host of the authorization callback (defaults to the configured one, or 127.0.0.1)")
+	cmd.MarkFlagsMutuallyExclusive("list", "logout")
+	cmd.MarkFlagsMutuallyExclusive("list", "headless")
 	return cmd
 }
+
+// profiles are the configured ones along with the ones holding any state
+func authList(cmd *cobra.Command) error {
+	data, err := config.Load(configPath(cmd))
+	if err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(sys.ProfilesDirectory())
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	profiles := []string{""}
+	for profile := range data.Profiles {
+		profiles = append(profiles, profile)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && !slices.Contains(profiles, entry.Name()) {
+			profiles = append(profiles, entry.Name())
+		}
+	}
+	slices.Sort(profiles)
+
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, profile := range profiles {
+		status := "not authenticated"
+		if _, err := os.Stat(sys.ProfileCacheFile(profile, spotify.TokenBasename)); err == nil {
+			status = "authenticated"
+		}
+		fmt.Fprintf(table, "%s\t%s\n", sys.Fallback(profile, "(default)"), status)
+	}
+	return table.Flush()
+}
+
+func authHeadlessProcessor(url string) error {
+	fmt.Printf("Open the following URL in a browser, then paste the URL it redirects to (or just its code):\n\n%s\n\n", url)
+	return nil
+}
@@ -2,11 +2,14 @@ package cmd
 
 import (
 	"errors"
+	"io"
 	"io/fs"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/bytedance/mockey"
+	"github.com/streambinder/spotitube/config"
 	"github.com/streambinder/spotitube/spotify"
 	"github.com/streambinder/spotitube/sys"
 	"github.com/stretchr/testify/assert"
@@ -55,3 +58,64 @@ func TestCmdAuthLogoutNotExists(t *testing.T) {
 	// testing
 	assert.Nil(t, sys.ErrOnly(testExecute(cmdAuth(), "--logout")))
 }
+
+func TestCmdAuthHeadless(t *testing.T) {
+	var input = filepath.Join(t.TempDir(), "redirect")
+	assert.Nil(t, os.WriteFile(input, []byte("C0D3"), 0o600))
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(spotify.SetRedirect).To(func(host string, port int) {
+		assert.Equal(t, "nas.local", host)
+		assert.Equal(t, 8080, port)
+	}).Build()
+	mockey.Mock(spotify.AuthenticateHeadless).To(func(urlProcessor func(string) error, reader io.Reader) (*spotify.Client, error) {
+		assert.Nil(t, urlProcessor("http://localhost/"))
+		return &spotify.Client{}, nil
+	}).Build()
+
+	// testing
+	assert.Nil(t, sys.ErrOnly(testExecute(cmdAuth(), "--headless", "--port", "8080", "--redirect-host", "nas.local")))
+	assert.Nil(t, sys.ErrOnly(testExecute(cmdAuth(), "--headless", "--input", input, "--port", "8080", "--redirect-host", "nas.local")))
+}
+
+func TestCmdAuthHeadlessInputFailure(t *testing.T) {
+	// testing
+	assert.ErrorIs(t, sys.ErrOnly(testExecute(cmdAuth(), "--headless", "--input", filepath.Join(t.TempDir(), "missing"))), fs.ErrNotExist)
+}
+
+func TestCmdAuthList(t *testing.T) {
+	root := t.TempDir()
+	testConfig(t, "profiles:\n  bob:\n    sync:\n      output: /path/to/bob\n")
+	assert.Nil(t, os.MkdirAll(filepath.Join(root, "profiles", "alice"), 0o755))
+	assert.Nil(t, os.MkdirAll(filepath.Join(root, "profiles", "bob"), 0o755))
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "profiles", "alice", spotify.TokenBasename), []byte{}, 0o600))
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "profiles", "notes.txt"), []byte{}, 0o600))
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(sys.CacheDirectory).Return(root).Build()
+
+	// testing
+	assert.Nil(t, sys.ErrOnly(testExecute(cmdAuth(), "--list")))
+}
+
+func TestCmdAuthListLoadFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(config.Load).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdAuth(), "--list")), "ko")
+}
+
+func TestCmdAuthListReadDirFailure(t *testing.T) {
+	testConfig(t, "")
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(os.ReadDir).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdAuth(), "--list")), "ko")
+}
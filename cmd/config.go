@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/streambinder/spotitube/config"
+	"github.com/streambinder/spotitube/entity/index"
+	"github.com/streambinder/spotitube/entity/journal"
+	"github.com/streambinder/spotitube/entity/playlist"
+	"github.com/streambinder/spotitube/provider"
+	"github.com/streambinder/spotitube/spotify"
+	"github.com/streambinder/spotitube/sys"
+	"gopkg.in/yaml.v3"
+)
+
+// as set by cobra on the flags of every mutually exclusive group
+const configExclusiveAnnotation = "cobra_annotation_mutually_exclusive"
+
+var profilePattern = regexp.MustCompile(`^[0-9A-Za-z_-]+$`)
+
+func init() {
+	cmdRoot.AddCommand(cmdConfig())
+	cmdRoot.PersistentFlags().String("config", config.Path(), "Configuration file path")
+	cmdRoot.PersistentFlags().String("profile", config.ProfileName(), "Profile to use, each with its own session, state and configuration")
+	cmdRoot.PersistentPreRunE = func(cmd *cobra.Command, _ []string) error {
+		return sys.ErrOnly(configApply(cmd))
+	}
+}
+
+func cmdConfig() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage configuration",
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:          "show",
+		Short:        "Show effective configuration",
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			data, err := configApply(cmd)
+			if err != nil {
+				return err
+			}
+
+			effective := map[string]interface{}{"providers": provider.Weights()}
+			if len(data.RedirectHost) > 0 {
+				effective["redirect_host"] = data.RedirectHost
+			}
+			if data.RedirectPort > 0 {
+				effective["redirect_port"] = data.RedirectPort
+			}
+			for env, key := range map[string]string{
+				"SPOTIFY_ID":   "spotify_id",
+				"SPOTIFY_KEY":  "spotify_key",
+				"GENIUS_TOKEN": "genius_token",
+			} {
+				if value := sys.Fallback(os.Getenv(env), data.Credentials()[env]); value != "" {
+					effective[key] = configMask(value)
+				}
+			}
+			for key := range data.Commands {
+				if command, _, err := cmdRoot.Find([]string{key}); err != nil || command == cmdRoot {
+					return fmt.Errorf("unknown command in configuration: %s", key)
+				}
+			}
+			for _, command := range cmdRoot.Commands() {
+				if err := configFlags(command, data); err != nil {
+					return err
+				}
+				configProfileOutput(command, configProfile(cmd))
+				flags := make(map[string]interface{})
+				command.LocalFlags().VisitAll(func(flag *pflag.Flag) {
+					if flag.Name != "help" {
+						flags[flag.Name] = configValue(flag)
+					}
+				})
+				if len(flags) > 0 {
+					effective[command.Name()] = flags
+				}
+			}
+
+			fmt.Printf("# %s\n", configPath(cmd))
+			if profile := configProfile(cmd); len(profile) > 0 {
+				fmt.Printf("# profile: %s\n", profile)
+			}
+			encoder := yaml.NewEncoder(os.Stdout)
+			encoder.SetIndent(2)
+			return encoder.Encode(effective)
+		},
+	})
+	return cmd
+}
+
+// loads the configuration file and applies it: credentials and flags
+// get set only if not already given via environment or command line,
+// while the authorization callback is set for every command to reuse
+func configApply(cmd *cobra.Command) (*config.Config, error) {
+	data, err := config.Load(configPath(cmd))
+	if err != nil {
+		return nil, err
+	}
+
+	profile := configProfile(cmd)
+	if err := configProfileApply(profile); err != nil {
+		return nil, err
+	}
+	data = data.Profile(profile)
+
+	for env, value := range data.Credentials() {
+		if _, ok := os.LookupEnv(env); !ok {
+			sys.ErrSuppress(os.Setenv(env, value))
+		}
+	}
+	if err := provider.SetWeights(data.Providers); err != nil {
+		return nil, err
+	}
+	spotify.SetRedirect(data.RedirectHost, data.RedirectPort)
+	if err := configFlags(cmd, data); err != nil {
+		return nil, err
+	}
+	configProfileOutput(cmd, profile)
+	return data, cmd.ValidateFlagGroups()
+}
+
+func configPath(cmd *cobra.Command) string {
+	if flag := cmd.Flags().Lookup("config"); flag != nil && flag.Changed {
+		return flag.Value.String()
+	}
+	return config.Path()
+}
+
+func configProfile(cmd *cobra.Command) string {
+	if flag := cmd.Flags().Lookup("profile"); flag != nil && flag.Changed {
+		return flag.Value.String()
+	}
+	return config.ProfileName()
+}
+
+// named profiles keep their session and synchronization state apart
+func configProfileApply(profile string) error {
+	if len(profile) == 0 {
+		return nil
+	}
+	if !profilePattern.MatchString(profile) {
+		return fmt.Errorf("invalid profile name: %s", profile)
+	}
+
+	spotify.SetProfile(profile)
+	indexPath = sys.ProfileCacheFile(profile, index.StoreBasename)
+	journalPath = sys.ProfileCacheFile(profile, journal.Basename)
+	snapshotsPath = sys.ProfileCacheFile(profile, playlist.SnapshotsBasename)
+	return nil
+}
+
+// unless otherwise configured, named profiles get
+// synchronized into their own output subfolder
+func configProfileOutput(cmd *cobra.Command, profile string) {
+	if flag := cmd.Flags().Lookup("output"); len(profile) > 0 && flag != nil && !flag.Changed {
+		// string flags are always settable
+		sys.ErrSuppress(cmd.Flags().Set("output", filepath.Join(flag.Value.String(), profile)))
+	}
+}
+
+// unchanged flags are set from the SPOTITUBE_<COMMAND>_<FLAG> environment variable,
+// if any, or from the configuration file, unless mutually exclusive with any flag
+// passed on the command line, which wins
+func configFlags(cmd *cobra.Command, data *config.Config) error {
+	key := configKey(cmd)
+	if key == "" {
+		return nil
+	}
+
+	values := data.Flags(key)
+	for name := range values {
+		if cmd.Flags().Lookup(name) == nil {
+			return fmt.Errorf("unknown %s flag in configuration: %s", key, name)
+		}
+	}
+
+	given := make(map[string]bool)
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		given[flag.Name] = true
+	})
+
+	var err error
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if err != nil || flag.Changed || configExcluded(flag, given) {
+			return
+		}
+		if value, ok := os.LookupEnv(configEnv(key, flag.Name)); ok {
+			err = cmd.Flags().Set(flag.Name, value)
+			return
+		}
+		for _, value := range values[flag.Name] {
+			if err = cmd.Flags().Set(flag.Name, value); err != nil {
+				return
+			}
+		}
+	})
+	return err
+}
+
+func configExcluded(flag *pflag.Flag, given map[string]bool) bool {
+	for _, group := range flag.Annotations[configExclusiveAnnotation] {
+		for _, name := range strings.Fields(group) {
+			if given[name] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// command path, stripped of the root command
+func configKey(cmd *cobra.Command) string {
+	names := []string{}
+	for ; cmd.HasParent(); cmd = cmd.Parent() {
+		names = append([]string{cmd.Name()}, names...)
+	}
+	return strings.Join(names, " ")
+}
+
+func configEnv(key, flag string) string {
+	return "SPOTITUBE_" + strings.ToUpper(strings.NewReplacer(" ", "_", "-", "_").Replace(key+"_"+flag))
+}
+
+func configValue(flag *pflag.Flag) interface{} {
+	if value, ok := flag.Value.(pflag.SliceValue); ok {
+		return value.GetSlice()
+	}
+	switch flag.Value.Type() {
+	case "bool":
+		return sys.ErrWrap(false)(strconv.ParseBool(flag.Value.String()))
+	case "int":
+		return sys.ErrWrap(0)(strconv.Atoi(flag.Value.String()))
+	}
+	return flag.Value.String()
+}
+
+func configMask(value string) string {
+	if len(value) <= 4 {
+		return strings.Repeat("*", len(value))
+	}
+	return strings.Repeat("*", len(value)-4) + value[len(value)-4:]
+}
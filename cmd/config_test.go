@@ -0,0 +1,243 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bytedance/mockey"
+	"github.com/spf13/cobra"
+	"github.com/streambinder/spotitube/config"
+	"github.com/streambinder/spotitube/entity/index"
+	"github.com/streambinder/spotitube/entity/journal"
+	"github.com/streambinder/spotitube/entity/playlist"
+	"github.com/streambinder/spotitube/provider"
+	"github.com/streambinder/spotitube/spotify"
+	"github.com/streambinder/spotitube/sys"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func BenchmarkConfig(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		TestCmdConfigShow(&testing.T{})
+	}
+}
+
+func testConfig(t *testing.T, data string) {
+	path := filepath.Join(t.TempDir(), config.Basename)
+	assert.Nil(t, os.WriteFile(path, []byte(data), 0o600))
+	t.Setenv("SPOTITUBE_CONFIG", path)
+	t.Setenv("SPOTIFY_ID", "")
+	assert.Nil(t, os.Unsetenv("SPOTIFY_ID"))
+	t.Setenv("SPOTIFY_KEY", "env")
+	t.Setenv("SPOTITUBE_PROFILE", "")
+	t.Cleanup(func() { sys.ErrSuppress(provider.SetWeights(nil)) })
+}
+
+// profiles move the state files, which must get back to the test ones
+func testConfigProfile(t *testing.T, profile string) {
+	t.Setenv("SPOTITUBE_PROFILE", profile)
+	previousIndexPath, previousJournalPath, previousSnapshotsPath := indexPath, journalPath, snapshotsPath
+	t.Cleanup(func() {
+		indexPath, journalPath, snapshotsPath = previousIndexPath, previousJournalPath, previousSnapshotsPath
+		spotify.SetProfile("")
+	})
+}
+
+func testConfigCommand() *cobra.Command {
+	cmd := cmdSync()
+	(&cobra.Command{}).AddCommand(cmd)
+	return cmd
+}
+
+func TestCmdConfigShow(t *testing.T) {
+	testConfig(t, "spotify_id: id\nspotify_key: key\ngenius_token: token\nproviders:\n  qobuz: 0\n")
+
+	// testing
+	assert.Nil(t, testExecute(cmdConfig(), "show"))
+	assert.Equal(t, map[string]int{"youtube": 100, "qobuz": 0}, provider.Weights())
+}
+
+func TestCmdConfigShowUnknownCommand(t *testing.T) {
+	testConfig(t, "command:\n  flag: value\n")
+
+	// testing
+	assert.EqualError(t, testExecute(cmdConfig(), "show"), "unknown command in configuration: command")
+}
+
+func TestCmdConfigShowFlagsFailure(t *testing.T) {
+	testConfig(t, "sync:\n  library-limit: value\n")
+
+	// testing
+	assert.NotNil(t, testExecute(cmdConfig(), "show"))
+}
+
+func TestCmdConfigShowLoadFailure(t *testing.T) {
+	testConfig(t, "")
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(config.Load).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, testExecute(cmdConfig(), "show"), "ko")
+}
+
+func TestCmdConfigShowEncodeFailure(t *testing.T) {
+	testConfig(t, "")
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&yaml.Encoder{}, "Encode")).Return(errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, testExecute(cmdConfig(), "show"), "ko")
+}
+
+func TestConfigApply(t *testing.T) {
+	testConfig(t, `spotify_id: id
+spotify_key: key
+providers:
+  youtube: 50
+redirect_host: 192.168.1.10
+redirect_port: 8080
+sync:
+  playlist: [a, b]
+  layout: nested
+  manual: true
+`)
+	t.Setenv("SPOTITUBE_SYNC_LAYOUT", "flat")
+	cmd := testConfigCommand()
+	assert.Nil(t, cmd.ParseFlags([]string{"--manual=false"}))
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(spotify.SetRedirect).To(func(host string, port int) {
+		assert.Equal(t, "192.168.1.10", host)
+		assert.Equal(t, 8080, port)
+	}).Build()
+
+	// testing
+	_, err := configApply(cmd)
+	assert.Nil(t, err)
+	assert.Equal(t, "id", os.Getenv("SPOTIFY_ID"))
+	assert.Equal(t, "env", os.Getenv("SPOTIFY_KEY"))
+	assert.Equal(t, 50, provider.Weights()["youtube"])
+	assert.Equal(t, []string{"a", "b"}, sys.ErrWrap([]string{})(cmd.Flags().GetStringArray("playlist")))
+	assert.Equal(t, "flat", sys.ErrWrap("")(cmd.Flags().GetString("layout")))
+	assert.False(t, sys.ErrWrap(true)(cmd.Flags().GetBool("manual")))
+}
+
+func TestConfigApplyPath(t *testing.T) {
+	testConfig(t, "")
+	cmd := testConfigCommand()
+	cmd.Flags().String("config", "", "")
+	assert.Nil(t, cmd.ParseFlags([]string{"--config", "/path/to/config.yaml"}))
+
+	// testing
+	assert.Equal(t, "/path/to/config.yaml", configPath(cmd))
+}
+
+func TestConfigApplyRoot(t *testing.T) {
+	testConfig(t, "sync:\n  manual: true\n")
+
+	// testing
+	assert.Nil(t, sys.ErrOnly(configApply(&cobra.Command{})))
+	assert.Nil(t, cmdRoot.PersistentPreRunE(cmdRoot, []string{}))
+}
+
+func TestConfigApplyUnknownFlag(t *testing.T) {
+	testConfig(t, "sync:\n  flag: value\n")
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(configApply(testConfigCommand())), "unknown sync flag in configuration: flag")
+}
+
+func TestConfigApplyEnvFailure(t *testing.T) {
+	testConfig(t, "")
+	t.Setenv("SPOTITUBE_SYNC_LIBRARY_LIMIT", "value")
+
+	// testing
+	assert.NotNil(t, sys.ErrOnly(configApply(testConfigCommand())))
+}
+
+func TestConfigApplyWeightsFailure(t *testing.T) {
+	testConfig(t, "providers:\n  provider: 1\n")
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(configApply(testConfigCommand())), "unknown provider: provider")
+}
+
+func TestConfigApplyFlagGroupsFailure(t *testing.T) {
+	testConfig(t, "sync:\n  prune: true\n  library-limit: 1\n")
+
+	// testing
+	assert.NotNil(t, sys.ErrOnly(configApply(testConfigCommand())))
+}
+
+func TestConfigApplyFlagGroupsCommandLine(t *testing.T) {
+	testConfig(t, "sync:\n  watch: 6h\n  layout: nested\n")
+	t.Setenv("SPOTITUBE_SYNC_PRUNE", "true")
+	cmd := testConfigCommand()
+	assert.Nil(t, cmd.ParseFlags([]string{"--dry-run", "--path-template", "{title}", "--library-limit", "1"}))
+
+	// testing
+	assert.Nil(t, sys.ErrOnly(configApply(cmd)))
+	assert.Zero(t, sys.ErrWrap(time.Hour)(cmd.Flags().GetDuration("watch")))
+	assert.False(t, sys.ErrWrap(true)(cmd.Flags().GetBool("prune")))
+	assert.Equal(t, "flat", sys.ErrWrap("")(cmd.Flags().GetString("layout")))
+}
+
+func TestConfigMask(t *testing.T) {
+	assert.Equal(t, "***", configMask("key"))
+	assert.Equal(t, "**cret", configMask("secret"))
+}
+
+func TestConfigApplyProfile(t *testing.T) {
+	testConfig(t, `sync:
+  playlist: [a]
+profiles:
+  alice:
+    spotify_id: alice
+    redirect_host: 192.168.1.10
+    redirect_port: 8080
+    sync:
+      playlist: [b]
+  bob:
+    sync:
+      output: /path/to/bob
+`)
+	testConfigProfile(t, "alice")
+	t.Cleanup(func() { spotify.SetRedirect("127.0.0.1", 65535) })
+
+	// testing
+	cmd := testConfigCommand()
+	_, err := configApply(cmd)
+	assert.Nil(t, err)
+	assert.Equal(t, "alice", os.Getenv("SPOTIFY_ID"))
+	assert.Equal(t, []string{"b"}, sys.ErrWrap([]string{})(cmd.Flags().GetStringArray("playlist")))
+	assert.Equal(t, "alice", filepath.Base(sys.ErrWrap("")(cmd.Flags().GetString("output"))))
+	assert.Equal(t, sys.ProfileCacheFile("alice", index.StoreBasename), indexPath)
+	assert.Equal(t, sys.ProfileCacheFile("alice", journal.Basename), journalPath)
+	assert.Equal(t, sys.ProfileCacheFile("alice", playlist.SnapshotsBasename), snapshotsPath)
+	assert.Equal(t, sys.ProfileCacheFile("alice", spotify.TokenBasename), spotify.TokenPath())
+	assert.Nil(t, testExecute(cmdConfig(), "show"))
+
+	cmd = testConfigCommand()
+	cmd.Flags().String("profile", "", "")
+	assert.Nil(t, cmd.ParseFlags([]string{"--profile", "bob"}))
+	_, err = configApply(cmd)
+	assert.Nil(t, err)
+	assert.Equal(t, "/path/to/bob", sys.ErrWrap("")(cmd.Flags().GetString("output")))
+}
+
+func TestConfigApplyProfileInvalid(t *testing.T) {
+	testConfig(t, "")
+	testConfigProfile(t, "../alice")
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(configApply(testConfigCommand())), "invalid profile name: ../alice")
+}
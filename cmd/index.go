@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/adrg/xdg"
+	"github.com/spf13/cobra"
+	"github.com/streambinder/spotitube/entity/index"
+	"github.com/streambinder/spotitube/sys"
+)
+
+func init() {
+	cmdRoot.AddCommand(cmdIndex())
+}
+
+func cmdIndex() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "index",
+		Short:        "Refresh the persistent index of local tracks",
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			var (
+				path    = sys.ErrWrap(xdg.UserDirs.Music)(cmd.Flags().GetString("output"))
+				rebuild = sys.ErrWrap(false)(cmd.Flags().GetBool("rebuild"))
+				data    = index.New()
+			)
+
+			if err := os.Chdir(path); err != nil {
+				return err
+			}
+
+			if err := data.Load(indexPath); err != nil {
+				return err
+			}
+			// rebuilding means not trusting any entry stored for this folder
+			if rebuild {
+				data.Forget(".")
+			}
+			if err := data.Build("."); err != nil {
+				return err
+			}
+
+			skipped := data.Skipped()
+			for _, path := range slices.Sorted(maps.Keys(skipped)) {
+				fmt.Printf("%s skipped: %v\n", path, skipped[path])
+			}
+			fmt.Printf("%d tracks indexed\n", data.Size())
+			return data.Save(indexPath)
+		},
+	}
+	cmd.Flags().StringP("output", "o", xdg.UserDirs.Music, "Output synchronization path")
+	cmd.Flags().Bool("rebuild", false, "Rebuild the index from scratch, parsing every track again")
+	return cmd
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bytedance/mockey"
+	"github.com/streambinder/spotitube/entity/index"
+	"github.com/streambinder/spotitube/sys"
+	"github.com/stretchr/testify/assert"
+)
+
+func BenchmarkIndex(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		TestCmdIndex(&testing.T{})
+	}
+}
+
+func TestCmdIndex(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	var (
+		path  = t.TempDir()
+		track = filepath.Join(path, "Artist - Title.mp3")
+		other = filepath.Join(t.TempDir(), "Artist - Title.mp3")
+		store = map[string]map[string]interface{}{track: {"id": "id"}, other: {"id": "id"}}
+	)
+	assert.Nil(t, os.MkdirAll(filepath.Dir(indexPath), 0o755))
+	assert.Nil(t, os.WriteFile(indexPath, sys.ErrWrap([]byte{})(json.Marshal(store)), 0o600))
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "BuildWithProgress")).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "Skipped")).Return(map[string]error{"Artist - Broken.mp3": errors.New("ko")}).Build()
+
+	// testing
+	assert.Nil(t, sys.ErrOnly(testExecute(cmdIndex(), "-o", path)))
+	assert.Nil(t, json.Unmarshal(sys.ErrWrap([]byte{})(os.ReadFile(indexPath)), &store))
+	assert.Len(t, store, 2)
+	assert.Nil(t, sys.ErrOnly(testExecute(cmdIndex(), "-o", path, "--rebuild")))
+	store = nil
+	assert.Nil(t, json.Unmarshal(sys.ErrWrap([]byte{})(os.ReadFile(indexPath)), &store))
+	assert.Len(t, store, 1)
+	assert.Contains(t, store, other)
+}
+
+func TestCmdIndexFailure(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(os.Chdir).Return(errors.New("ko chdir")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdIndex())), "ko chdir")
+}
+
+func TestCmdIndexLoadFailure(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "Load")).Return(errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdIndex(), "-o", t.TempDir())), "ko")
+}
+
+func TestCmdIndexBuildFailure(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "BuildWithProgress")).Return(errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdIndex(), "-o", t.TempDir(), "--rebuild")), "ko")
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/adrg/xdg"
+	"github.com/spf13/cobra"
+	"github.com/streambinder/spotitube/entity"
+	"github.com/streambinder/spotitube/entity/tags"
+	"github.com/streambinder/spotitube/spotify"
+	"github.com/streambinder/spotitube/sys"
+)
+
+func init() {
+	cmdRoot.AddCommand(cmdLike())
+}
+
+func cmdLike() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "like",
+		Short:        "Save local tracks to Spotify library",
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			var (
+				path   = sys.ErrWrap(xdg.UserDirs.Music)(cmd.Flags().GetString("output"))
+				dryRun = sys.ErrWrap(false)(cmd.Flags().GetBool("dry-run"))
+				diff   = sys.ErrWrap(false)(cmd.Flags().GetBool("diff"))
+			)
+
+			ids, paths, untagged, err := likeWalk(path)
+			if err != nil {
+				return err
+			}
+
+			client, err := spotify.Authenticate(spotify.BrowserProcessor)
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			saved, err := client.LibraryContains(ids)
+			if err != nil {
+				return err
+			}
+
+			var unsaved []string
+			for _, id := range ids {
+				marker := "="
+				if !saved[id] {
+					marker = "+"
+					unsaved = append(unsaved, id)
+				}
+				if diff {
+					fmt.Printf("%s %s (id: %s)\n", marker, paths[id], id)
+				}
+			}
+			fmt.Printf("%d tracks to be saved, %d already saved, %d without Spotify ID\n", len(unsaved), len(ids)-len(unsaved), untagged)
+
+			if dryRun {
+				return nil
+			}
+			return client.LibraryAdd(unsaved)
+		},
+	}
+	cmd.Flags().StringP("output", "o", xdg.UserDirs.Music, "Synchronization path to walk through")
+	cmd.Flags().Bool("dry-run", false, "Only tell which tracks would be saved, without saving them")
+	cmd.Flags().Bool("diff", false, "List every track, marking the ones to be saved (+) and the ones already saved (=)")
+	return cmd
+}
+
+// tracks are collected by the Spotify ID they are tagged with,
+// each bound to the first path found to carry it
+func likeWalk(root string) ([]string, map[string]string, int, error) {
+	var (
+		ids      []string
+		paths    = make(map[string]string)
+		untagged int
+	)
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// hidden folders (e.g. trash bins) are skipped, as in indexing
+		if entry.IsDir() {
+			if path != root && strings.HasPrefix(entry.Name(), ".") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !entity.IsTrack(path) {
+			return nil
+		}
+
+		tag, err := tags.Open(path, tags.ParseSpotifyID)
+		if err != nil {
+			return err
+		}
+		id := tag.SpotifyID()
+		if err := tag.Close(); err != nil {
+			return err
+		}
+
+		if len(id) == 0 {
+			untagged++
+		} else if _, ok := paths[id]; !ok {
+			ids = append(ids, id)
+			paths[id] = path
+		}
+		return nil
+	})
+	return ids, paths, untagged, err
+}
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bytedance/mockey"
+	"github.com/streambinder/spotitube/entity/id3"
+	"github.com/streambinder/spotitube/entity/vorbis"
+	"github.com/streambinder/spotitube/spotify"
+	"github.com/streambinder/spotitube/sys"
+	"github.com/streambinder/spotitube/sys/cmd"
+	"github.com/stretchr/testify/assert"
+)
+
+func BenchmarkLike(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		TestCmdLike(&testing.T{})
+	}
+}
+
+func testLikeFolder(t *testing.T) string {
+	path := t.TempDir()
+	assert.Nil(t, os.Mkdir(filepath.Join(path, ".trash"), 0o755))
+	testPushTrack(t, filepath.Join(path, "a.mp3"), "123", "", "")
+	testPushTrack(t, filepath.Join(path, "b.mp3"), "123", "", "")
+	testPushTrack(t, filepath.Join(path, "c.mp3"), "456", "", "")
+	testPushTrack(t, filepath.Join(path, "d.mp3"), "", "", "")
+	testPushTrack(t, filepath.Join(path, ".trash", "e.mp3"), "789", "", "")
+	assert.Nil(t, os.WriteFile(filepath.Join(path, "notes.txt"), []byte{}, 0o600))
+	return path
+}
+
+func TestCmdLike(t *testing.T) {
+	var (
+		path  = testLikeFolder(t)
+		added []string
+	)
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(spotify.Authenticate).Return(&spotify.Client{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Close")).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "LibraryContains")).To(func(ids []string) (map[string]bool, error) {
+		assert.Equal(t, []string{"123", "456"}, ids)
+		return map[string]bool{"123": true}, nil
+	}).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "LibraryAdd")).To(func(ids []string) error {
+		added = ids
+		return nil
+	}).Build()
+
+	// testing
+	assert.Nil(t, sys.ErrOnly(testExecute(cmdLike(), "-o", path, "--dry-run", "--diff")))
+	assert.Nil(t, added)
+	assert.Nil(t, sys.ErrOnly(testExecute(cmdLike(), "-o", path)))
+	assert.Equal(t, []string{"456"}, added)
+}
+
+func TestCmdLikeFormats(t *testing.T) {
+	var (
+		path  = testLikeFolder(t)
+		added []string
+	)
+	assert.Nil(t, os.WriteFile(filepath.Join(path, "f.flac"), []byte{}, 0o600))
+	assert.Nil(t, os.WriteFile(filepath.Join(path, "g.m4a"), []byte{}, 0o600))
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(vorbis.Open).Return(&vorbis.Tag{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&vorbis.Tag{}, "SpotifyID")).Return("789").Build()
+	mockey.Mock(mockey.GetMethod(cmd.FFmpegCmd{}, "Tags")).Return(map[string]string{"spotify_id": "012"}, nil).Build()
+	mockey.Mock(spotify.Authenticate).Return(&spotify.Client{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Close")).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "LibraryContains")).Return(map[string]bool{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "LibraryAdd")).To(func(ids []string) error {
+		added = ids
+		return nil
+	}).Build()
+
+	// testing
+	assert.Nil(t, sys.ErrOnly(testExecute(cmdLike(), "-o", path)))
+	assert.ElementsMatch(t, []string{"123", "456", "789", "012"}, added)
+}
+
+func TestCmdLikeVorbisFailure(t *testing.T) {
+	path := testLikeFolder(t)
+	assert.Nil(t, os.WriteFile(filepath.Join(path, "f.opus"), []byte{}, 0o600))
+
+	// testing
+	assert.Error(t, sys.ErrOnly(testExecute(cmdLike(), "-o", path)))
+}
+
+func TestCmdLikeTagsFailure(t *testing.T) {
+	path := testLikeFolder(t)
+	assert.Nil(t, os.WriteFile(filepath.Join(path, "f.m4a"), []byte{}, 0o600))
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(cmd.FFmpegCmd{}, "Tags")).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdLike(), "-o", path)), "ko")
+}
+
+func TestCmdLikeWalkFailure(t *testing.T) {
+	assert.Error(t, sys.ErrOnly(testExecute(cmdLike(), "-o", filepath.Join(t.TempDir(), "missing"))))
+}
+
+func TestCmdLikeOpenFailure(t *testing.T) {
+	path := testLikeFolder(t)
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(id3.OpenSpotifyID).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdLike(), "-o", path)), "ko")
+}
+
+func TestCmdLikeCloseFailure(t *testing.T) {
+	path := testLikeFolder(t)
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&id3.Tag{}, "Close")).Return(errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdLike(), "-o", path)), "ko")
+}
+
+func TestCmdLikeAuthFailure(t *testing.T) {
+	path := testLikeFolder(t)
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(spotify.Authenticate).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdLike(), "-o", path)), "ko")
+}
+
+func TestCmdLikeLibraryContainsFailure(t *testing.T) {
+	path := testLikeFolder(t)
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(spotify.Authenticate).Return(&spotify.Client{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Close")).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "LibraryContains")).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdLike(), "-o", path)), "ko")
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/streambinder/spotitube/entity/playlist"
+	"github.com/streambinder/spotitube/entity/tags"
+	"github.com/streambinder/spotitube/spotify"
+)
+
+func init() {
+	cmdRoot.AddCommand(cmdPush())
+}
+
+func cmdPush() *cobra.Command {
+	return &cobra.Command{
+		Use:          "push",
+		Short:        "Push local playlists to Spotify",
+		SilenceUsage: true,
+		Args:         cobra.MinimumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			client, err := spotify.Authenticate(spotify.BrowserProcessor)
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			var unresolved int
+			for _, path := range args {
+				localPlaylist, references, err := playlist.Decode(path)
+				if err != nil {
+					return err
+				}
+
+				var (
+					ids    []string
+					failed int
+				)
+				for _, reference := range references {
+					id, err := pushResolve(client, reference)
+					if err != nil {
+						fmt.Printf("%s could not be resolved: %v\n", reference, err)
+						failed++
+						continue
+					}
+					ids = append(ids, id)
+				}
+
+				// the remote playlist gets replaced altogether, so it is left
+				// untouched unless every entry resolved and there is any
+				if failed > 0 {
+					fmt.Printf("%s not pushed, as %d entries could not be resolved\n", path, failed)
+					unresolved += failed
+					continue
+				}
+				if len(ids) == 0 {
+					fmt.Printf("%s not pushed, as it has no entries\n", path)
+					continue
+				}
+
+				remotePlaylist, err := client.PushPlaylist(localPlaylist.Name, ids)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("%s pushed to %s (id: %s) with %d tracks\n", path, remotePlaylist.Name, remotePlaylist.ID, len(ids))
+			}
+
+			if unresolved > 0 {
+				return fmt.Errorf("%d entries could not be resolved", unresolved)
+			}
+			return nil
+		},
+	}
+}
+
+// entries are resolved by the Spotify ID they are tagged with
+// or, if missing, by looking their title and artist up
+func pushResolve(client *spotify.Client, path string) (string, error) {
+	tag, err := tags.Open(path, tags.ParseAll)
+	if err != nil {
+		return "", err
+	}
+	defer tag.Close()
+
+	if id := tag.SpotifyID(); len(id) > 0 {
+		return id, nil
+	}
+	if len(tag.Title()) == 0 || len(tag.Artist()) == 0 {
+		return "", errors.New("neither Spotify ID nor title and artist tagged")
+	}
+
+	track, err := client.SearchTrack(tag.Title(), tag.Artist())
+	if err != nil {
+		return "", err
+	}
+	return track.ID, nil
+}
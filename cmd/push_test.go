@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bogem/id3v2/v2"
+	"github.com/bytedance/mockey"
+	"github.com/streambinder/spotitube/entity"
+	"github.com/streambinder/spotitube/entity/id3"
+	"github.com/streambinder/spotitube/entity/playlist"
+	"github.com/streambinder/spotitube/spotify"
+	"github.com/streambinder/spotitube/sys"
+	"github.com/stretchr/testify/assert"
+)
+
+func BenchmarkPush(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		TestCmdPush(&testing.T{})
+	}
+}
+
+func testPushTrack(t *testing.T, path, id, title, artist string) {
+	assert.Nil(t, os.WriteFile(path, []byte{}, 0o600))
+	tag, err := id3.Open(path, id3v2.Options{Parse: false})
+	assert.Nil(t, err)
+	tag.SetSpotifyID(id)
+	tag.SetTitle(title)
+	tag.SetArtist(artist)
+	assert.Nil(t, tag.Save())
+	assert.Nil(t, tag.Close())
+}
+
+func testPushPlaylist(t *testing.T) string {
+	path := t.TempDir()
+	testPushTrack(t, filepath.Join(path, "tagged.mp3"), "123", "", "")
+	testPushTrack(t, filepath.Join(path, "untagged.mp3"), "", "Title", "Artist")
+	testPushTrack(t, filepath.Join(path, "unknown.mp3"), "", "", "")
+	assert.Nil(t, os.WriteFile(filepath.Join(path, "playlist.m3u"), []byte(`#EXTM3U
+#PLAYLIST:Playlist
+tagged.mp3
+untagged.mp3
+`), 0o600))
+	assert.Nil(t, os.WriteFile(filepath.Join(path, "unresolved.pls"), []byte(`[playlist]
+File1=unknown.mp3
+File2=missing.mp3
+File3=untagged.mp3
+`), 0o600))
+	assert.Nil(t, os.WriteFile(filepath.Join(path, "empty.m3u"), []byte("#EXTM3U\n"), 0o600))
+	return path
+}
+
+func TestCmdPush(t *testing.T) {
+	var (
+		path   = testPushPlaylist(t)
+		pushed = make(map[string][]string)
+	)
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(spotify.Authenticate).Return(&spotify.Client{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Close")).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "SearchTrack")).Return(&entity.Track{ID: "456"}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "PushPlaylist")).To(func(name string, ids []string) (*playlist.Playlist, error) {
+		pushed[name] = ids
+		return &playlist.Playlist{ID: "789", Name: name}, nil
+	}).Build()
+
+	// testing
+	assert.Nil(t, sys.ErrOnly(testExecute(cmdPush(), filepath.Join(path, "playlist.m3u"))))
+	assert.Equal(t, []string{"123", "456"}, pushed["Playlist"])
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdPush(), filepath.Join(path, "unresolved.pls"), filepath.Join(path, "empty.m3u"))), "2 entries could not be resolved")
+	assert.NotContains(t, pushed, "unresolved")
+	assert.NotContains(t, pushed, "empty")
+}
+
+func TestCmdPushAuthFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(spotify.Authenticate).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdPush(), "playlist.m3u")), "ko")
+}
+
+func TestCmdPushDecodeFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(spotify.Authenticate).Return(&spotify.Client{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Close")).Return(nil).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdPush(), "playlist.xspf")), "unsupported encoding")
+}
+
+func TestCmdPushSearchFailure(t *testing.T) {
+	var (
+		path   = testPushPlaylist(t)
+		pushes = 0
+	)
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(spotify.Authenticate).Return(&spotify.Client{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Close")).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "SearchTrack")).Return(nil, errors.New("ko")).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "PushPlaylist")).To(func(string, []string) (*playlist.Playlist, error) {
+		pushes++
+		return &playlist.Playlist{}, nil
+	}).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdPush(), filepath.Join(path, "playlist.m3u"))), "1 entries could not be resolved")
+	assert.Zero(t, pushes)
+}
+
+func TestCmdPushPlaylistFailure(t *testing.T) {
+	path := testPushPlaylist(t)
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(spotify.Authenticate).Return(&spotify.Client{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Close")).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "SearchTrack")).Return(&entity.Track{ID: "456"}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "PushPlaylist")).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdPush(), filepath.Join(path, "playlist.m3u"))), "ko")
+}
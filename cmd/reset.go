@@ -43,6 +43,11 @@ func cmdReset() *cobra.Command {
 				if cacheDirectory == path || (entry.Name() == spotify.TokenBasename && !session) {
 					return nil
 				}
+				// profiles folders are walked through, for their sessions to be preserved
+				if entry.IsDir() && !session &&
+					(path == sys.ProfilesDirectory() || filepath.Dir(path) == sys.ProfilesDirectory()) {
+					return nil
+				}
 
 				rel := strings.TrimPrefix(path, cacheDirectory+string(filepath.Separator))
 				if err := rootRemoveAll(root, rel); err != nil {
@@ -72,8 +72,11 @@ func TestCmdReset(t *testing.T) {
 		name  string
 		isDir bool
 	}{
-		{spotify.TokenBasename, false}, // should be preserved (no --session)
-		{"fname.txt", false},           // should be removed
+		{spotify.TokenBasename, false},       // should be preserved (no --session)
+		{"fname.txt", false},                 // should be removed
+		{"profiles", true},                   // should be walked through (no --session)
+		{"profiles/alice", true},             // should be walked through (no --session)
+		{"profiles/alice/index.json", false}, // should be removed
 	})).Build()
 	mockey.Mock(rootRemoveAll).Return(nil).Build()
 
@@ -113,6 +116,7 @@ func TestCmdResetSession(t *testing.T) {
 		isDir bool
 	}{
 		{spotify.TokenBasename, false}, // with --session, token should also be removed
+		{"profiles", true},             // with --session, profiles should also be removed
 	})).Build()
 	mockey.Mock(rootRemoveAll).Return(nil).Build()
 
@@ -5,10 +5,10 @@ import (
 	"os"
 	"text/tabwriter"
 
-	"github.com/bogem/id3v2/v2"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
-	"github.com/streambinder/spotitube/entity/id3"
+	"github.com/streambinder/spotitube/entity/tags"
+	"github.com/streambinder/spotitube/lyrics"
 	"github.com/streambinder/spotitube/sys"
 )
 
@@ -28,7 +28,7 @@ func cmdShow() *cobra.Command {
 			bold := color.New(color.Bold)
 			for i, path := range args {
 				if err := func() error {
-					tag, err := id3.Open(path, id3v2.Options{Parse: true})
+					tag, err := tags.Open(path, tags.ParseAll)
 					if err != nil {
 						return err
 					}
@@ -51,6 +51,12 @@ func cmdShow() *cobra.Command {
 					}(tag.Duration()))
 					fmt.Fprintln(table, "Upstream URL\t", sys.Fallback(tag.UpstreamURL(), fallback))
 					fmt.Fprintln(table, "Lyrics\t", sys.Fallback(sys.Excerpt(sys.FirstLine(tag.UnsynchronizedLyrics()), 64), fallback))
+					fmt.Fprintln(table, "Synced lyrics\t", func(lines []lyrics.SyncedLine) string {
+						if len(lines) > 0 {
+							return sys.Excerpt(fmt.Sprintf("[%s] %s", sys.MillisToColonMinutes(lines[0].Time), lines[0].Text), 64)
+						}
+						return fallback
+					}(tag.SynchronizedLyrics()))
 					fmt.Fprintln(table, "Artwork\t", func(mimeType string, data []byte) string {
 						if len(data) > 0 {
 							return fmt.Sprintf("%s (%s)", mimeType, sys.HumanizeBytes(len(data)))
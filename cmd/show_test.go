@@ -6,7 +6,10 @@ import (
 
 	"github.com/bytedance/mockey"
 	"github.com/streambinder/spotitube/entity/id3"
+	"github.com/streambinder/spotitube/entity/vorbis"
+	"github.com/streambinder/spotitube/lyrics"
 	"github.com/streambinder/spotitube/sys"
+	"github.com/streambinder/spotitube/sys/cmd"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -22,6 +25,7 @@ func TestCmdShow(t *testing.T) {
 	mockey.Mock(id3.Open).Return(&id3.Tag{}, nil).Build()
 	mockey.Mock(mockey.GetMethod(&id3.Tag{}, "AttachedPicture")).Return("image/jpeg", []byte("some picture data")).Build()
 	mockey.Mock(mockey.GetMethod(&id3.Tag{}, "Duration")).Return("60").Build()
+	mockey.Mock(mockey.GetMethod(&id3.Tag{}, "SynchronizedLyrics")).Return([]lyrics.SyncedLine{{Time: 27370, Text: "lyrics"}}).Build()
 
 	// testing
 	assert.Nil(t, sys.ErrOnly(testExecute(cmdShow(), "path/to/track1", "path/to/track2")))
@@ -44,3 +48,14 @@ func TestCmdShowPictureFallback(t *testing.T) {
 	// testing
 	assert.Nil(t, sys.ErrOnly(testExecute(cmdShow(), "path/to/track")))
 }
+
+func TestCmdShowFormats(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(vorbis.Open).Return(&vorbis.Tag{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&vorbis.Tag{}, "SpotifyID")).Return("vorbis").Build()
+	mockey.Mock(mockey.GetMethod(cmd.FFmpegCmd{}, "Tags")).Return(map[string]string{"spotify_id": "ffmeta"}, nil).Build()
+
+	// testing
+	assert.Nil(t, sys.ErrOnly(testExecute(cmdShow(), "path/to/track.flac", "path/to/track.m4a")))
+}
@@ -4,9 +4,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"os"
+	"os/signal"
+	"path"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/adrg/xdg"
 	"github.com/arunsworld/nursery"
@@ -16,7 +26,10 @@ import (
 	"github.com/streambinder/spotitube/entity"
 	"github.com/streambinder/spotitube/entity/id3"
 	"github.com/streambinder/spotitube/entity/index"
+	"github.com/streambinder/spotitube/entity/journal"
 	"github.com/streambinder/spotitube/entity/playlist"
+	"github.com/streambinder/spotitube/entity/report"
+	"github.com/streambinder/spotitube/entity/tags"
 	"github.com/streambinder/spotitube/lyrics"
 	"github.com/streambinder/spotitube/processor"
 	"github.com/streambinder/spotitube/provider"
@@ -45,9 +58,91 @@ var (
 	routineSemaphores map[int](chan bool)
 	routineQueues     map[int](chan interface{})
 	indexData         = index.New()
+	indexPath         = sys.CacheFile(index.StoreBasename)
+	journalData       = journal.New()
+	journalPath       = sys.CacheFile(journal.Basename)
+	snapshotsData     = playlist.NewSnapshots()
+	snapshotsPath     = sys.CacheFile(playlist.SnapshotsBasename)
+	fetchedPlaylists  map[string]*playlist.Playlist
+	reportData        = report.New()
+	failures          = &trackFailures{}
+	references        = &trackReferences{}
+	planned           = &trackReferences{} // tracks to be synchronized, in dry-run mode
 	tui               = anchor.New(anchor.Red)
 )
 
+// collections to be fetched from Spotify
+type syncCollections struct {
+	library          bool
+	libraryLimit     int
+	savedAlbums      bool
+	allPlaylists     bool
+	playlistsInclude []string // name globs
+	playlistsExclude []string // name globs
+	playlists        []string
+	playlistsTracks  []string
+	albums           []string
+	artists          []string
+	artistFilter     spotify.ArtistFilter
+	tracks           []string
+	fixes            []string
+	retryFailed      bool
+}
+
+// a track failure is bound to the single track it occurred on:
+// it gets recorded and reported at the end of the run, without
+// halting the synchronization of the rest of the collection
+type trackFailure struct {
+	track *entity.Track
+	stage string
+	err   error
+}
+
+type trackFailures struct {
+	entries []trackFailure
+	lock    sync.Mutex
+}
+
+func (failures *trackFailures) add(track *entity.Track, stage string, err error) {
+	failures.lock.Lock()
+	defer failures.lock.Unlock()
+	failures.entries = append(failures.entries, trackFailure{track, stage, err})
+}
+
+func (failures *trackFailures) list() []trackFailure {
+	failures.lock.Lock()
+	defer failures.lock.Unlock()
+	return append([]trackFailure{}, failures.entries...)
+}
+
+// every fetched track is referenced by some collection:
+// indexed tracks which are not are the ones to be pruned
+type trackReferences struct {
+	ids  map[string]bool
+	lock sync.Mutex
+}
+
+func (references *trackReferences) add(track *entity.Track) {
+	references.lock.Lock()
+	defer references.lock.Unlock()
+	if references.ids == nil {
+		references.ids = make(map[string]bool)
+	}
+	references.ids[track.ID] = true
+}
+
+func (references *trackReferences) has(id string) bool {
+	references.lock.Lock()
+	defer references.lock.Unlock()
+	return references.ids[id]
+}
+
+func (references *trackReferences) size() int {
+	references.lock.Lock()
+	defer references.lock.Unlock()
+	return len(references.ids)
+}
+
 func init() {
 	cmdRoot.AddCommand(cmdSync())
 }
@@ -68,15 +163,49 @@ func cmdSync() *cobra.Command {
 				playlistEncoding = sys.ErrWrap("m3u")(cmd.Flags().GetString("playlist-encoding"))
 				manual           = sys.ErrWrap(false)(cmd.Flags().GetBool("manual"))
 				library          = sys.ErrWrap(false)(cmd.Flags().GetBool("library"))
+				savedAlbums      = sys.ErrWrap(false)(cmd.Flags().GetBool("saved-albums"))
+				allPlaylists     = sys.ErrWrap(false)(cmd.Flags().GetBool("all-playlists"))
+				playlistsInclude = sys.ErrWrap([]string{})(cmd.Flags().GetStringArray("playlists-include"))
+				playlistsExclude = sys.ErrWrap([]string{})(cmd.Flags().GetStringArray("playlists-exclude"))
 				playlists        = sys.ErrWrap([]string{})(cmd.Flags().GetStringArray("playlist"))
 				playlistsTracks  = sys.ErrWrap([]string{})(cmd.Flags().GetStringArray("playlist-tracks"))
 				albums           = sys.ErrWrap([]string{})(cmd.Flags().GetStringArray("album"))
+				artists          = sys.ErrWrap([]string{})(cmd.Flags().GetStringArray("artist"))
+				artistAlbumTypes = sys.ErrWrap([]string{})(cmd.Flags().GetStringSlice("artist-album-types"))
+				artistMarket     = sys.ErrWrap("")(cmd.Flags().GetString("artist-market"))
 				tracks           = sys.ErrWrap([]string{})(cmd.Flags().GetStringArray("track"))
 				fixes            = sys.ErrWrap([]string{})(cmd.Flags().GetStringArray("fix"))
 				libraryLimit     = sys.ErrWrap(0)(cmd.Flags().GetInt("library-limit"))
+				retryFailed      = sys.ErrWrap(false)(cmd.Flags().GetBool("retry-failed"))
+				dryRun           = sys.ErrWrap(false)(cmd.Flags().GetBool("dry-run"))
+				reportPath       = sys.ErrWrap("")(cmd.Flags().GetString("report"))
+				prune            = sys.ErrWrap(false)(cmd.Flags().GetBool("prune"))
+				pruneTrash       = sys.ErrWrap("")(cmd.Flags().GetString("prune-trash"))
+				pruneRetain      = sys.ErrWrap(false)(cmd.Flags().GetBool("prune-retain"))
+				format           = sys.ErrWrap(entity.FormatMP3)(cmd.Flags().GetString("format"))
+				layout           = sys.ErrWrap(entity.LayoutFlat)(cmd.Flags().GetString("layout"))
+				pathTemplate     = sys.ErrWrap("")(cmd.Flags().GetString("path-template"))
+				lyricsSYLT       = sys.ErrWrap(false)(cmd.Flags().GetBool("lyrics-sylt"))
+				lyricsSidecar    = sys.ErrWrap(false)(cmd.Flags().GetBool("lyrics-sidecar"))
+				searchWorkers    = sys.ErrWrap(1)(cmd.Flags().GetInt("search-workers"))
+				downloadWorkers  = sys.ErrWrap(1)(cmd.Flags().GetInt("download-workers"))
+				processWorkers   = sys.ErrWrap(1)(cmd.Flags().GetInt("process-workers"))
 				plain            = sys.ErrWrap(false)(cmd.Flags().GetBool("plain"))
+				watch            = sys.ErrWrap(time.Duration(0))(cmd.Flags().GetDuration("watch"))
+				force            = sys.ErrWrap(false)(cmd.Flags().GetBool("force"))
 			)
 
+			if err := entity.SetFormat(format); err != nil {
+				return err
+			}
+			if err := entity.SetLayout(layout); err != nil {
+				return err
+			}
+			if err := entity.SetPathTemplate(pathTemplate); err != nil {
+				return err
+			}
+			id3.SetSynchronizedLyrics(lyricsSYLT)
+
 			if plain {
 				tui.EnablePlainMode()
 			}
@@ -85,49 +214,129 @@ func cmdSync() *cobra.Command {
 				absPath, absErr := filepath.Abs(path)
 				fixes[index] = sys.Ternary(absErr == nil, absPath, path)
 			}
+			if len(reportPath) > 0 {
+				absPath, absErr := filepath.Abs(reportPath)
+				reportPath = sys.Ternary(absErr == nil, absPath, reportPath)
+			}
+			if len(pruneTrash) > 0 {
+				absPath, absErr := filepath.Abs(pruneTrash)
+				pruneTrash = sys.Ternary(absErr == nil, absPath, pruneTrash)
+			}
 
 			if err := os.Chdir(path); err != nil {
 				return err
 			}
 
-			if err := nursery.RunConcurrently(
-				routineIndex,
-				routineAuth,
-				routineFetch(library, playlists, playlistsTracks, albums, tracks, fixes, libraryLimit),
-				routineDecide(manual),
-				routineCollect,
-				routineProcess,
-				routineInstall,
-				routineMix(playlistEncoding),
-			); err != nil {
+			var err error
+			if journalData, err = journal.Load(journalPath); err != nil {
+				return err
+			}
+			if snapshotsData, err = playlist.LoadSnapshots(snapshotsPath); err != nil {
 				return err
 			}
 
-			tui.Printf("synchronization complete")
-			return nil
-		},
-		PreRun: func(cmd *cobra.Command, _ []string) {
-			routineSemaphores = map[int](chan bool){
-				routineTypeIndex:   make(chan bool, 1),
-				routineTypeAuth:    make(chan bool, 1),
-				routineTypeInstall: make(chan bool, 1),
+			cycle := func() error {
+				routines := []nursery.ConcurrentJob{
+					routineIndex(pruneTrash),
+					routineAuth,
+					// pruning needs every collection to be fetched in full
+					routineFetch(syncCollections{
+						library:          library,
+						libraryLimit:     libraryLimit,
+						savedAlbums:      savedAlbums,
+						allPlaylists:     allPlaylists,
+						playlistsInclude: playlistsInclude,
+						playlistsExclude: playlistsExclude,
+						playlists:        playlists,
+						playlistsTracks:  playlistsTracks,
+						albums:           albums,
+						artists:          artists,
+						artistFilter:     spotify.ArtistFilter{AlbumTypes: artistAlbumTypes, Market: artistMarket},
+						tracks:           tracks,
+						fixes:            fixes,
+						retryFailed:      retryFailed,
+					}, force || prune, playlistEncoding),
+					routineDecide(manual, dryRun, searchWorkers),
+				}
+				if dryRun {
+					routines = append(routines, routinePlan(playlistEncoding))
+				} else {
+					routines = append(routines,
+						routineCollect(downloadWorkers),
+						routineProcess(processWorkers),
+						routineInstall(lyricsSidecar),
+						routineMix(playlistEncoding),
+					)
+				}
+				if err := nursery.RunConcurrently(routines...); err != nil {
+					return err
+				}
+
+				if prune {
+					if err := routinePrune(pruneTrash, pruneRetain, lyricsSidecar, dryRun); err != nil {
+						return err
+					}
+				}
+
+				for _, failure := range failures.list() {
+					reason := failure.stage + ": " + failure.err.Error()
+					journalData.Fail(failure.track, reason)
+					reportData.Update(failure.track, func(entry *report.Entry) { entry.Error = reason })
+				}
+				if len(reportPath) > 0 {
+					if err := saveReport(reportPath); err != nil {
+						return err
+					}
+				}
+
+				if dryRun {
+					return nil
+				}
+
+				if err := indexData.Save(indexPath); err != nil {
+					return err
+				}
+				if err := journalData.Save(journalPath); err != nil {
+					return err
+				}
+				syncSnapshots()
+				if err := snapshotsData.Save(snapshotsPath); err != nil {
+					return err
+				}
+
+				if failed := failures.list(); len(failed) > 0 {
+					tui.Printf("synchronization completed with %d failures:", len(failed))
+					for _, failure := range failed {
+						tui.Printf("%s by %s (id: %s) failed on %s: %s",
+							failure.track.Title, failure.track.Artists[0], failure.track.ID, failure.stage, failure.err)
+					}
+					return fmt.Errorf("%d tracks failed to synchronize", len(failed))
+				}
+
+				tui.Printf("synchronization complete")
+				return nil
 			}
-			routineQueues = map[int](chan interface{}){
-				routineTypeDecide:  make(chan interface{}, pipelineBuffer),
-				routineTypeCollect: make(chan interface{}, pipelineBuffer),
-				routineTypeProcess: make(chan interface{}, pipelineBuffer),
-				routineTypeInstall: make(chan interface{}, pipelineBuffer),
-				routineTypeMix:     make(chan interface{}, pipelineBuffer),
+			if watch > 0 {
+				return syncWatch(watch, cycle)
 			}
+			return cycle()
+		},
+		PreRun: func(cmd *cobra.Command, _ []string) {
+			spotifyClient = nil
+			syncReset()
 
 			var (
 				playlists       = sys.ErrWrap([]string{})(cmd.Flags().GetStringArray("playlist"))
 				playlistsTracks = sys.ErrWrap([]string{})(cmd.Flags().GetStringArray("playlist-tracks"))
 				albums          = sys.ErrWrap([]string{})(cmd.Flags().GetStringArray("album"))
+				artists         = sys.ErrWrap([]string{})(cmd.Flags().GetStringArray("artist"))
+				savedAlbums     = sys.ErrWrap(false)(cmd.Flags().GetBool("saved-albums"))
+				allPlaylists    = sys.ErrWrap(false)(cmd.Flags().GetBool("all-playlists"))
 				tracks          = sys.ErrWrap([]string{})(cmd.Flags().GetStringArray("track"))
 				fixes           = sys.ErrWrap([]string{})(cmd.Flags().GetStringArray("fix"))
+				retryFailed     = sys.ErrWrap(false)(cmd.Flags().GetBool("retry-failed"))
 			)
-			if len(playlists)+len(playlistsTracks)+len(albums)+len(tracks)+len(fixes) == 0 {
+			if len(playlists)+len(playlistsTracks)+len(albums)+len(artists)+len(tracks)+len(fixes) == 0 && !savedAlbums && !allPlaylists && !retryFailed {
 				cmd.LocalFlags().VisitAll(func(f *pflag.Flag) {
 					if f.Name == "library" {
 						sys.ErrSuppress(f.Value.Set("true"))
@@ -142,47 +351,184 @@ func cmdSync() *cobra.Command {
 	cmd.Flags().BoolP("library", "l", false, "Synchronize library (auto-enabled if no collection is supplied)")
 	cmd.Flags().StringArrayP("playlist", "p", []string{}, "Synchronize playlist")
 	cmd.Flags().StringArray("playlist-tracks", []string{}, "Synchronize playlist tracks without playlist file")
+	cmd.Flags().Bool("all-playlists", false, "Synchronize every playlist owned or followed")
+	cmd.Flags().StringArray("playlists-include", []string{}, "Only synchronize the playlists whose name matches the given glob (with --all-playlists)")
+	cmd.Flags().StringArray("playlists-exclude", []string{}, "Do not synchronize the playlists whose name matches the given glob (with --all-playlists)")
+	cmd.Flags().Bool("saved-albums", false, "Synchronize every saved album")
 	cmd.Flags().StringArrayP("album", "a", []string{}, "Synchronize album")
+	cmd.Flags().StringArray("artist", []string{}, "Synchronize artist discography (by ID, URL or name)")
+	cmd.Flags().StringSlice("artist-album-types", []string{"album", "single"}, "Artist discography release types (album, single, compilation, appears_on)")
+	cmd.Flags().String("artist-market", "", "Artist discography market, as ISO 3166-1 alpha-2 country code (defaults to the user's one)")
 	cmd.Flags().StringArrayP("track", "t", []string{}, "Synchronize track")
 	cmd.Flags().StringArrayP("fix", "f", []string{}, "Fix local track")
 	cmd.Flags().Int("library-limit", 0, "Number of tracks to fetch from library (unlimited if 0)")
+	cmd.Flags().Bool("retry-failed", false, "Synchronize tracks which previously failed to, once their retry backoff elapsed")
+	cmd.Flags().Bool("dry-run", false, "Only show what would be synchronized, without downloading nor writing anything")
+	cmd.Flags().String("report", "", "Write a JSON report of every fetched track to the given path")
+	cmd.Flags().Bool("prune", false, "Remove local tracks which are not referenced by any of the synchronized collections anymore")
+	cmd.Flags().String("prune-trash", "", "Move pruned tracks to the given folder instead of deleting them")
+	cmd.Flags().Bool("prune-retain", false, "Keep pruned tracks which are still referenced by any local playlist file")
+	// pruning needs the collections to be fetched in full, as anything
+	// not referenced by them is gone, hence no partial selection
+	cmd.MarkFlagsMutuallyExclusive("prune", "library-limit")
+	cmd.MarkFlagsMutuallyExclusive("prune", "track")
+	cmd.MarkFlagsMutuallyExclusive("prune", "album")
+	cmd.MarkFlagsMutuallyExclusive("prune", "retry-failed")
+	cmd.MarkFlagsMutuallyExclusive("prune", "fix")
+	cmd.Flags().String("format", entity.FormatMP3, "Tracks output format ("+strings.Join(entity.TrackFormats(), ", ")+")")
+	cmd.Flags().String("layout", entity.LayoutFlat, "Tracks folder layout (flat: Artist - Title.mp3, nested: Artist/Album/NN - Title.mp3)")
+	cmd.Flags().String("path-template", "", "Tracks path template, relative to the output path (e.g. {album_artist}/{year} - {album}/{number:02} {title})")
+	cmd.MarkFlagsMutuallyExclusive("layout", "path-template")
+	cmd.Flags().Bool("lyrics-sylt", false, "Write synced lyrics to a dedicated ID3 SYLT frame, leaving plain ones in USLT (instead of LRC)")
+	cmd.Flags().Bool("lyrics-sidecar", false, "Write lyrics beside tracks too, as Artist - Title.lrc (synced) or Artist - Title.txt (plain)")
+	cmd.Flags().Int("search-workers", 1, "Number of tracks to be looked up on providers concurrently")
+	cmd.Flags().Int("download-workers", 1, "Number of tracks to be downloaded concurrently")
+	cmd.Flags().Int("process-workers", 1, "Number of tracks to be processed concurrently")
+	cmd.Flags().Bool("force", false, "Synchronize playlists even if unchanged since their last synchronization")
+	cmd.Flags().Duration("watch", 0, "Keep synchronizing on the given interval (e.g. 6h), until terminated")
+	cmd.MarkFlagsMutuallyExclusive("watch", "dry-run")
+	cmd.MarkFlagsMutuallyExclusive("watch", "manual")
+	cmd.MarkFlagsMutuallyExclusive("watch", "fix")
+	cmd.MarkFlagsMutuallyExclusive("watch", "prune")
 	cmd.Flags().Bool("plain", false, "Enable plain mode (no fancy TUI anchored output)")
 	return cmd
 }
 
+// every synchronization cycle starts
+// from a clean pipeline and bookkeeping
+func syncReset() {
+	indexData = index.New()
+	failures = &trackFailures{}
+	reportData = report.New()
+	references = &trackReferences{}
+	planned = &trackReferences{}
+	fetchedPlaylists = make(map[string]*playlist.Playlist)
+	routineSemaphores = map[int](chan bool){
+		routineTypeIndex:   make(chan bool, 1),
+		routineTypeAuth:    make(chan bool, 1),
+		routineTypeInstall: make(chan bool, 1),
+	}
+	routineQueues = map[int](chan interface{}){
+		routineTypeDecide:  make(chan interface{}, pipelineBuffer),
+		routineTypeCollect: make(chan interface{}, pipelineBuffer),
+		routineTypeProcess: make(chan interface{}, pipelineBuffer),
+		routineTypeInstall: make(chan interface{}, pipelineBuffer),
+		routineTypeMix:     make(chan interface{}, pipelineBuffer),
+	}
+}
+
+// snapshots are only stored for the playlists whose tracks all got synchronized,
+// so that the failed or skipped ones are looked for again on the next synchronization
+func syncSnapshots() {
+	failed := make(map[string]bool)
+	for _, entry := range reportData.Entries() {
+		if len(entry.Error) > 0 {
+			failed[entry.ID] = true
+		}
+	}
+
+	for id, playlist := range fetchedPlaylists {
+		if !slices.ContainsFunc(playlist.Tracks, func(track *entity.Track) bool { return failed[track.ID] }) {
+			snapshotsData.Set(id, playlist.SnapshotID)
+		}
+	}
+}
+
+// watcher runs a synchronization cycle on every interval, until terminated:
+// a failing cycle does not stop it and an ongoing one is always let complete
+func syncWatch(interval time.Duration, cycle func() error) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	for {
+		if err := cycle(); err != nil {
+			tui.Printf("synchronization failed: %s", err)
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		tui.Printf("next synchronization at %s", time.Now().Add(interval).Format(time.DateTime))
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+			syncReset()
+		}
+	}
+}
+
 // indexer scans a possible local music library
 // to be considered as already synchronized
-func routineIndex(_ context.Context, ch chan error) {
-	// remember to signal fetcher
-	defer close(routineSemaphores[routineTypeIndex])
+func routineIndex(trash string) func(context.Context, chan error) {
+	return func(_ context.Context, ch chan error) {
+		// remember to signal fetcher
+		defer close(routineSemaphores[routineTypeIndex])
 
-	indexed := make(chan string)
-	defer close(indexed)
-	go func() {
-		counter := 0
-		for path := range indexed {
-			counter++
-			tui.Lot("index").Printf("%s", filepath.Base(path))
+		// trashed tracks are not to be indexed again
+		if len(trash) > 0 {
+			indexData.Exclude(trash)
 		}
-		tui.Lot("index").Close(strconv.Itoa(counter) + " tracks")
-	}()
 
-	tui.Lot("index").Printf("scanning")
-	if err := indexData.BuildWithProgress(".", indexed); err != nil {
-		tui.Printf("indexing failed: %s", err)
-		routineSemaphores[routineTypeIndex] <- false
-		ch <- err
-		return
+		// wait for progress to be drained before returning
+		indexed, indexedDone := make(chan string), make(chan bool)
+		defer func() {
+			close(indexed)
+			<-indexedDone
+		}()
+		go func() {
+			defer close(indexedDone)
+			counter := 0
+			for path := range indexed {
+				counter++
+				tui.Lot("index").Printf("%s", filepath.Base(path))
+			}
+			tui.Lot("index").Close(strconv.Itoa(counter) + " tracks")
+		}()
+
+		tui.Lot("index").Printf("scanning")
+		if err := routineIndexBuild(indexed); err != nil {
+			tui.Printf("indexing failed: %s", err)
+			routineSemaphores[routineTypeIndex] <- false
+			ch <- err
+			return
+		}
+		skipped := indexData.Skipped()
+		for _, path := range slices.Sorted(maps.Keys(skipped)) {
+			tui.AnchorPrintf("%s skipped: %v", path, skipped[path])
+		}
+
+		// once indexed, signal fetcher
+		routineSemaphores[routineTypeIndex] <- true
 	}
+}
 
-	// once indexed, signal fetcher
-	routineSemaphores[routineTypeIndex] <- true
+// only tracks changed since the last
+// persisted index need to be parsed again
+func routineIndexBuild(indexed chan<- string) error {
+	if err := indexData.Load(indexPath); err != nil {
+		return err
+	}
+	return indexData.BuildWithProgress(".", indexed)
 }
 
 func routineAuth(_ context.Context, ch chan error) {
 	// remember to close auth semaphore
 	defer close(routineSemaphores[routineTypeAuth])
 
+	// a client kept alive across watch cycles
+	// only needs its refreshed token persisted
+	if spotifyClient != nil {
+		if err := spotifyClient.Persist(); err != nil {
+			tui.Printf("session persistence failed: %s", err)
+			routineSemaphores[routineTypeAuth] <- false
+			ch <- err
+			return
+		}
+		routineSemaphores[routineTypeAuth] <- true
+		return
+	}
+
 	tui.Lot("auth").Printf("authenticating")
 	var err error
 	spotifyClient, err = spotify.Authenticate(spotify.BrowserProcessor)
@@ -207,7 +553,7 @@ func routineAuth(_ context.Context, ch chan error) {
 
 // fetcher pulls data from the upstream
 // provider, i.e. Spotify
-func routineFetch(library bool, playlists, playlistsTracks, albums, tracks, fixes []string, libraryLimit int) func(ctx context.Context, ch chan error) {
+func routineFetch(collections syncCollections, force bool, encoding string) func(ctx context.Context, ch chan error) {
 	return func(_ context.Context, ch chan error) {
 		// remember to stop passing data to decider and mixer
 		defer close(routineQueues[routineTypeDecide])
@@ -220,30 +566,52 @@ func routineFetch(library bool, playlists, playlistsTracks, albums, tracks, fixe
 			return
 		}
 
-		fetched := make(chan interface{})
-		defer close(fetched)
+		// wait for progress to be drained before returning
+		fetched, fetchedDone := make(chan interface{}), make(chan bool)
+		defer func() {
+			close(fetched)
+			<-fetchedDone
+		}()
 		go func() {
+			defer close(fetchedDone)
 			counter := 0
 			for event := range fetched {
 				counter++
 				track := event.(*entity.Track)
+				reportData.Update(track)
+				references.add(track)
 				tui.Lot("fetch").Printf("%s by %s", track.Title, track.Artists[0])
 			}
 			tui.Lot("fetch").Close(fmt.Sprintf("%d tracks", counter))
 		}()
 
-		fixesTracks, fixesErr := routineFetchFixesIDs(fixes)
+		fixesTracks, fixesErr := routineFetchFixesIDs(collections.fixes)
 		if fixesErr != nil {
 			ch <- fixesErr
 			return
 		}
-		tracks = append(tracks, fixesTracks...)
+		tracks := append(collections.tracks, fixesTracks...)
 
-		if err := routineFetchLibrary(library, libraryLimit, fetched); err != nil {
+		playlists, playlistsErr := routineFetchAllPlaylists(collections.allPlaylists, collections.playlistsInclude, collections.playlistsExclude)
+		if playlistsErr != nil {
+			ch <- playlistsErr
+			return
+		}
+		playlists = append(collections.playlists, playlists...)
+
+		if err := routineFetchLibrary(collections.library, collections.libraryLimit, fetched); err != nil {
 			ch <- err
 			return
 		}
-		if err := routineFetchAlbums(albums, fetched); err != nil {
+		if err := routineFetchSavedAlbums(collections.savedAlbums, fetched); err != nil {
+			ch <- err
+			return
+		}
+		if err := routineFetchAlbums(collections.albums, fetched); err != nil {
+			ch <- err
+			return
+		}
+		if err := routineFetchArtists(collections.artists, collections.artistFilter, fetched); err != nil {
 			ch <- err
 			return
 		}
@@ -251,10 +619,11 @@ func routineFetch(library bool, playlists, playlistsTracks, albums, tracks, fixe
 			ch <- err
 			return
 		}
-		if err := routineFetchPlaylists(append(playlists, playlistsTracks...), len(playlists), fetched); err != nil {
+		if err := routineFetchPlaylists(append(playlists, collections.playlistsTracks...), len(playlists), force, encoding, fetched); err != nil {
 			ch <- err
 			return
 		}
+		routineFetchRetries(collections.retryFailed, fetched)
 	}
 }
 
@@ -262,7 +631,7 @@ func routineFetchFixesIDs(fixes []string) ([]string, error) {
 	var localTracks []string
 	for _, path := range fixes {
 		tui.Lot("fetch").Printf("track %s", path)
-		tag, err := id3.OpenSpotifyID(path)
+		tag, err := tags.Open(path, tags.ParseSpotifyID)
 		if err != nil {
 			return nil, err
 		}
@@ -290,6 +659,15 @@ func routineFetchLibrary(library bool, libraryLimit int, fetched chan interface{
 	return spotifyClient.Library(libraryLimit, routineQueues[routineTypeDecide], fetched)
 }
 
+func routineFetchSavedAlbums(savedAlbums bool, fetched chan interface{}) error {
+	if !savedAlbums {
+		return nil
+	}
+
+	tui.Lot("fetch").Printf("saved albums")
+	return spotifyClient.SavedAlbums(routineQueues[routineTypeDecide], fetched)
+}
+
 func routineFetchAlbums(albums []string, fetched chan interface{}) error {
 	for _, id := range albums {
 		tui.Lot("fetch").Printf("album %s", id)
@@ -300,6 +678,16 @@ func routineFetchAlbums(albums []string, fetched chan interface{}) error {
 	return nil
 }
 
+func routineFetchArtists(artists []string, filter spotify.ArtistFilter, fetched chan interface{}) error {
+	for _, id := range artists {
+		tui.Lot("fetch").Printf("artist %s", id)
+		if err := spotifyClient.Artist(id, filter, routineQueues[routineTypeDecide], fetched); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func routineFetchTracks(tracks []string, fetched chan interface{}) error {
 	for _, id := range tracks {
 		tui.Lot("fetch").Printf("track %s", id)
@@ -310,13 +698,107 @@ func routineFetchTracks(tracks []string, fetched chan interface{}) error {
 	return nil
 }
 
-func routineFetchPlaylists(playlists []string, playlistsWithFile int, fetched chan interface{}) error {
+// a playlist is skipped if its contents did not change since its
+// last synchronization and its file, if any, is still in place
+func routineFetchPlaylistUnchanged(id string, withFile bool, encoding string) (bool, error) {
+	snapshot := snapshotsData.Get(id)
+	if len(snapshot) == 0 {
+		return false, nil
+	}
+
+	playlist, err := spotifyClient.PlaylistSnapshot(id)
+	if err != nil {
+		return false, err
+	}
+	if playlist.SnapshotID != snapshot {
+		return false, nil
+	}
+	if !withFile {
+		return true, nil
+	}
+
+	encoder, err := playlist.Encoder(encoding)
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(encoder.Target())
+	return err == nil, nil
+}
+
+// retries are not fatal: a track which cannot even be fetched
+// anymore gets its journal entry backed off further
+func routineFetchRetries(retryFailed bool, fetched chan interface{}) {
+	if !retryFailed {
+		return
+	}
+
+	for _, entry := range journalData.Due(time.Now()) {
+		tui.Lot("fetch").Printf("retry %s by %s", entry.Title, entry.Artist)
+		if _, err := spotifyClient.Track(entry.ID, routineQueues[routineTypeDecide], fetched); err != nil {
+			tui.AnchorPrintf("%s by %s (id: %s) fetch failed: %v", entry.Title, entry.Artist, entry.ID, err)
+			journalData.Fail(&entity.Track{ID: entry.ID, Title: entry.Title, Artists: []string{entry.Artist}}, "fetch failed: "+err.Error())
+		}
+	}
+}
+
+// all the playlists owned or followed by the user get synchronized, as long as
+// their name matches any of the include globs, if any, and none of the exclude ones
+func routineFetchAllPlaylists(allPlaylists bool, include, exclude []string) ([]string, error) {
+	if !allPlaylists {
+		return nil, nil
+	}
+
+	tui.Lot("fetch").Printf("playlists")
+	playlists, err := spotifyClient.Playlists()
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, playlist := range playlists {
+		included, err := routineFetchPlaylistsMatch(playlist.Name, include)
+		if err != nil {
+			return nil, err
+		}
+		excluded, err := routineFetchPlaylistsMatch(playlist.Name, exclude)
+		if err != nil {
+			return nil, err
+		}
+		if (len(include) == 0 || included) && !excluded {
+			ids = append(ids, playlist.ID)
+		}
+	}
+	return ids, nil
+}
+
+func routineFetchPlaylistsMatch(name string, patterns []string) (bool, error) {
+	for _, pattern := range patterns {
+		if match, err := path.Match(pattern, name); err != nil || match {
+			return match, err
+		}
+	}
+	return false, nil
+}
+
+func routineFetchPlaylists(playlists []string, playlistsWithFile int, force bool, encoding string, fetched chan interface{}) error {
 	for index, id := range playlists {
 		tui.Lot("fetch").Printf("playlist %s", id)
+		if !force {
+			unchanged, err := routineFetchPlaylistUnchanged(id, index < playlistsWithFile, encoding)
+			if err != nil {
+				return err
+			}
+			if unchanged {
+				tui.Printf("playlist %s unchanged since last synchronization, skipped", id)
+				continue
+			}
+		}
+
 		playlist, err := spotifyClient.Playlist(id, routineQueues[routineTypeDecide], fetched)
 		if err != nil {
 			return err
 		}
+		fetchedPlaylists[id] = playlist
 		if index < playlistsWithFile {
 			routineQueues[routineTypeMix] <- playlist
 		}
@@ -326,7 +808,7 @@ func routineFetchPlaylists(playlists []string, playlistsWithFile int, fetched ch
 
 // decider finds the right asset to retrieve
 // for a given track
-func routineDecide(manualMode bool) func(context.Context, chan error) {
+func routineDecide(manualMode, dryRun bool, workers int) func(context.Context, chan error) {
 	return func(_ context.Context, _ chan error) {
 		// remember to stop passing data to the collector
 		// the retriever, the composer and the painter
@@ -334,85 +816,139 @@ func routineDecide(manualMode bool) func(context.Context, chan error) {
 
 		// consecutive search failures trigger a circuit breaker:
 		// if all providers fail repeatedly, stop wasting time retrying
-		consecutiveFailures := 0
+		var consecutiveFailures atomic.Int32
 		const maxConsecutiveFailures = 3
 
-		for event := range routineQueues[routineTypeDecide] {
-			track := event.(*entity.Track)
+		// user input cannot be prompted concurrently
+		if manualMode {
+			workers = 1
+		}
 
-			if status, ok := indexData.Get(track); !ok {
-				tui.Printf("sync %s by %s", track.Title, track.Artists[0])
-				indexData.Set(track, index.Online)
-			} else if status == index.Online {
-				tui.Printf("skip %s by %s", track.Title, track.Artists[0])
-				continue
-			} else if status == index.Offline {
-				continue
-			}
+		routinePool(workers, func() {
+			for event := range routineQueues[routineTypeDecide] {
+				track := event.(*entity.Track)
 
-			if manualMode {
-				tui.Lot("decide").Printf("waiting on user input")
-				track.UpstreamURL = tui.Reads("URL for %s by %s:", track.Title, track.Artists[0])
-				tui.Lot("decide").Wipe()
-				if len(track.UpstreamURL) == 0 {
+				if owner, ok := indexData.Collision(track); ok {
+					tui.AnchorPrintf("%s by %s (id: %s) skipped: %s collides with track %s", track.Title, track.Artists[0], track.ID, track.Path().Final(), owner)
+					routineDecideSkip(track, "collides with track "+owner)
 					continue
 				}
-			} else {
-				if consecutiveFailures >= maxConsecutiveFailures {
-					tui.AnchorPrintf("%s by %s (id: %s) skipped: search unavailable", track.Title, track.Artists[0], track.ID)
+
+				if status, ok := indexData.SetIfAbsent(track, index.Online); !ok {
+					tui.Printf("sync %s by %s", track.Title, track.Artists[0])
+				} else if status == index.Online || status == index.Installed {
+					tui.Printf("skip %s by %s", track.Title, track.Artists[0])
+					continue
+				} else if status == index.Offline {
+					if dryRun {
+						tui.Printf("skip %s by %s: already synchronized", track.Title, track.Artists[0])
+					}
+					journalData.Remove(track.ID)
 					continue
 				}
 
-				tui.Lot("decide").Printf("%s by %s", track.Title, track.Artists[0])
-				matches, err := provider.Search(track)
-				tui.Lot("decide").Wipe()
-				if err != nil {
-					consecutiveFailures++
-					tui.AnchorPrintf("%s by %s (id: %s) search failed: %v", track.Title, track.Artists[0], track.ID, err)
-					continue
+				score := 0 // user-issued URLs are not scored
+				if manualMode {
+					tui.Lot("decide").Printf("waiting on user input")
+					track.UpstreamURL = tui.Reads("URL for %s by %s:", track.Title, track.Artists[0])
+					tui.Lot("decide").Wipe()
+					if len(track.UpstreamURL) == 0 {
+						routineDecideSkip(track, "no URL given")
+						continue
+					}
+				} else {
+					if consecutiveFailures.Load() >= maxConsecutiveFailures {
+						tui.AnchorPrintf("%s by %s (id: %s) skipped: search unavailable", track.Title, track.Artists[0], track.ID)
+						routineDecideFail(track, "search unavailable")
+						continue
+					}
+
+					tui.Lot("decide").Printf("%s by %s", track.Title, track.Artists[0])
+					start := time.Now()
+					matches, err := provider.Search(track)
+					routineTrace(track, "decide", start)
+					tui.Lot("decide").Wipe()
+					if err != nil {
+						consecutiveFailures.Add(1)
+						tui.AnchorPrintf("%s by %s (id: %s) search failed: %v", track.Title, track.Artists[0], track.ID, err)
+						routineDecideFail(track, "search failed: "+err.Error())
+						continue
+					}
+
+					consecutiveFailures.Store(0)
+					if len(matches) == 0 {
+						tui.AnchorPrintf("%s by %s (id: %s) not found", track.Title, track.Artists[0], track.ID)
+						routineDecideFail(track, "not found")
+						continue
+					}
+					track.UpstreamURL, score = matches[0].URL, matches[0].Score
 				}
+				reportData.Update(track, func(entry *report.Entry) {
+					entry.UpstreamURL, entry.UpstreamScore = track.UpstreamURL, score
+				})
 
-				consecutiveFailures = 0
-				if len(matches) == 0 {
-					tui.AnchorPrintf("%s by %s (id: %s) not found", track.Title, track.Artists[0], track.ID)
+				// in dry-run mode, tracks are only marked as planned,
+				// for the planner to evaluate playlists against
+				if dryRun {
+					status, _ := indexData.Get(track)
+					tui.Printf("%s %s by %s from %s (score: %d)",
+						sys.Ternary(status == index.Flush, "flush", "sync"), track.Title, track.Artists[0], track.UpstreamURL, score)
+					planned.add(track)
 					continue
 				}
-				track.UpstreamURL = matches[0].URL
+				routineQueues[routineTypeCollect] <- track
 			}
-			routineQueues[routineTypeCollect] <- track
-		}
+		})
 		tui.Lot("decide").Close()
 	}
 }
 
+// decider failures do not fail the synchronization, yet they
+// are kept track of for further retries and reporting
+func routineDecideFail(track *entity.Track, reason string) {
+	journalData.Fail(track, reason)
+	reportData.Update(track, func(entry *report.Entry) { entry.Error = reason })
+}
+
+// skipped tracks are not worth a retry backoff, yet they are reported
+// and keep their playlists from being considered synchronized
+func routineDecideSkip(track *entity.Track, reason string) {
+	reportData.Update(track, func(entry *report.Entry) { entry.Error = "skipped: " + reason })
+}
+
 // collector fetches all the needed assets
 // for a blob to be processed (basically
 // a wrapper around: retriever, composer and painter)
-func routineCollect(_ context.Context, ch chan error) {
-	// remember to stop passing data to installer
-	defer close(routineQueues[routineTypeProcess])
-
-	for event := range routineQueues[routineTypeCollect] {
-		track := event.(*entity.Track)
-		if err := nursery.RunConcurrently(
-			routineCollectAsset(track),
-			routineCollectLyrics(track),
-			routineCollectArtwork(track),
-		); err != nil {
-			ch <- err
-			return
-		}
-		routineQueues[routineTypeProcess] <- track
+func routineCollect(workers int) func(context.Context, chan error) {
+	return func(_ context.Context, _ chan error) {
+		// remember to stop passing data to installer
+		defer close(routineQueues[routineTypeProcess])
+
+		routinePool(workers, func() {
+			for event := range routineQueues[routineTypeCollect] {
+				track := event.(*entity.Track)
+				if err := nursery.RunConcurrently(
+					routineCollectAsset(track),
+					routineCollectLyrics(track),
+					routineCollectArtwork(track),
+				); err != nil {
+					failures.add(track, "collect", err)
+					continue
+				}
+				routineQueues[routineTypeProcess] <- track
+			}
+		})
+		tui.Lot("download").Close()
+		tui.Lot("compose").Close()
+		tui.Lot("paint").Close()
 	}
-	tui.Lot("download").Close()
-	tui.Lot("compose").Close()
-	tui.Lot("paint").Close()
 }
 
 // retriever pulls a track blob corresponding
 // to the (meta)data fetched from upstream
 func routineCollectAsset(track *entity.Track) func(context.Context, chan error) {
 	return func(_ context.Context, ch chan error) {
+		defer routineTrace(track, "download", time.Now())
 		tui.Lot("download").Print(track.UpstreamURL)
 		if err := downloader.Download(track.UpstreamURL, track.Path().Download(), nil); err != nil {
 			tui.AnchorPrintf("download failure: %s", err)
@@ -428,16 +964,20 @@ func routineCollectAsset(track *entity.Track) func(context.Context, chan error)
 // in the fetched blob
 func routineCollectLyrics(track *entity.Track) func(context.Context, chan error) {
 	return func(_ context.Context, ch chan error) {
+		defer routineTrace(track, "compose", time.Now())
 		tui.Lot("compose").Printf("%s by %s", track.Title, track.Artists[0])
-		lyrics, err := lyrics.Search(track)
+		composition, source, err := lyrics.SearchWithSource(track)
 		if err != nil {
 			tui.AnchorPrintf("compose failure: %s", err)
 			ch <- err
 			return
 		}
 		tui.Lot("compose").Wipe()
-		track.Lyrics = lyrics
-		tui.Printf("lyrics for %s by %s: %s", track.Title, track.Artists[0], sys.Fallback(sys.Excerpt(sys.FirstLine(lyrics), 64), "not found"))
+		track.Lyrics = composition
+		reportData.Update(track, func(entry *report.Entry) {
+			entry.LyricsSource, entry.LyricsSynced = source, lyrics.IsSynced(composition)
+		})
+		tui.Printf("lyrics for %s by %s: %s", track.Title, track.Artists[0], sys.Fallback(sys.Excerpt(sys.FirstLine(composition), 64), "not found"))
 	}
 }
 
@@ -445,6 +985,7 @@ func routineCollectLyrics(track *entity.Track) func(context.Context, chan error)
 // as artworks in the fetched blob
 func routineCollectArtwork(track *entity.Track) func(context.Context, chan error) {
 	return func(_ context.Context, ch chan error) {
+		defer routineTrace(track, "paint", time.Now())
 		artwork := make(chan []byte, 1)
 		defer close(artwork)
 
@@ -457,6 +998,7 @@ func routineCollectArtwork(track *entity.Track) func(context.Context, chan error
 
 		tui.Lot("paint").Wipe()
 		track.Artwork.Data = <-artwork
+		reportData.Update(track, func(entry *report.Entry) { entry.ArtworkSize = len(track.Artwork.Data) })
 		tui.Printf("artwork for %s by %s: %s", track.Title, track.Artists[0], sys.HumanizeBytes(len(track.Artwork.Data)))
 	}
 }
@@ -464,44 +1006,102 @@ func routineCollectArtwork(track *entity.Track) func(context.Context, chan error
 // postprocessor applies some further enhancements
 // e.g. combining the downloaded artwork/lyrics
 // into the blob
-func routineProcess(_ context.Context, ch chan error) {
-	// remember to stop passing data to installer
-	defer close(routineQueues[routineTypeInstall])
-
-	for event := range routineQueues[routineTypeProcess] {
-		track := event.(*entity.Track)
-		tui.Lot("process").Printf("%s by %s", track.Title, track.Artists[0])
-		if err := processor.Do(track); err != nil {
-			tui.AnchorPrintf("processing failed for %s by %s: %s", track.Title, track.Artists[0], err)
-			ch <- err
-			return
-		}
-		tui.Lot("process").Wipe()
-		routineQueues[routineTypeInstall] <- track
+func routineProcess(workers int) func(context.Context, chan error) {
+	return func(_ context.Context, _ chan error) {
+		// remember to stop passing data to installer
+		defer close(routineQueues[routineTypeInstall])
+
+		routinePool(workers, func() {
+			for event := range routineQueues[routineTypeProcess] {
+				track := event.(*entity.Track)
+				tui.Lot("process").Printf("%s by %s", track.Title, track.Artists[0])
+				start := time.Now()
+				err := processor.Do(track)
+				routineTrace(track, "process", start)
+				if err != nil {
+					tui.AnchorPrintf("processing failed for %s by %s: %s", track.Title, track.Artists[0], err)
+					failures.add(track, "process", err)
+					continue
+				}
+				tui.Lot("process").Wipe()
+				routineQueues[routineTypeInstall] <- track
+			}
+		})
+		tui.Lot("process").Close()
 	}
-	tui.Lot("process").Close()
+}
+
+// spreads a stage over a pool of workers, all consuming
+// the same queue, and waits for every one of them to be done
+func routinePool(workers int, work func()) {
+	var wg sync.WaitGroup
+	for range max(workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			work()
+		}()
+	}
+	wg.Wait()
 }
 
 // installer move the blob to its final destination
-func routineInstall(_ context.Context, ch chan error) {
-	// remember to signal mixer
-	defer close(routineSemaphores[routineTypeInstall])
-
-	for event := range routineQueues[routineTypeInstall] {
-		var (
-			track     = event.(*entity.Track)
-			status, _ = indexData.Get(track)
-		)
-		tui.Lot("install").Printf("%s by %s ", track.Title, track.Artists[0])
-		if err := sys.FileMoveOrCopy(track.Path().Download(), track.Path().Final(), status == index.Flush); err != nil {
-			tui.AnchorPrintf("installation failed for %s by %s: %s", track.Title, track.Artists[0], err)
-			ch <- err
-			return
+func routineInstall(sidecar bool) func(context.Context, chan error) {
+	return func(_ context.Context, _ chan error) {
+		// remember to signal mixer
+		defer close(routineSemaphores[routineTypeInstall])
+
+		for event := range routineQueues[routineTypeInstall] {
+			var (
+				track     = event.(*entity.Track)
+				status, _ = indexData.Get(track)
+			)
+			tui.Lot("install").Printf("%s by %s ", track.Title, track.Artists[0])
+			start := time.Now()
+			err := routineInstallTrack(track, status == index.Flush)
+			if err == nil && sidecar {
+				err = routineInstallSidecar(track)
+			}
+			routineTrace(track, "install", start)
+			if err != nil {
+				tui.AnchorPrintf("installation failed for %s by %s: %s", track.Title, track.Artists[0], err)
+				failures.add(track, "install", err)
+				continue
+			}
+			tui.Lot("install").Wipe()
+			indexData.Set(track, index.Installed)
+			journalData.Remove(track.ID)
 		}
-		tui.Lot("install").Wipe()
-		indexData.Set(track, index.Installed)
+		tui.Lot("install").Close(strconv.Itoa(indexData.Size(index.Installed)) + " tracks")
+	}
+}
+
+// final paths may be nested, depending on the layout
+func routineInstallTrack(track *entity.Track, overwrite bool) error {
+	if err := os.MkdirAll(filepath.Dir(track.Path().Final()), 0o755); err != nil {
+		return err
 	}
-	tui.Lot("install").Close(strconv.Itoa(indexData.Size(index.Installed)) + " tracks")
+	return sys.FileMoveOrCopy(track.Path().Download(), track.Path().Final(), overwrite)
+}
+
+// lyrics get copied out of the composer cache, which is kept
+// for the track to be fixed later on, into a sidecar whose
+// extension tells synced lyrics apart from plain ones
+func routineInstallSidecar(track *entity.Track) error {
+	data, err := os.ReadFile(track.Path().Lyrics())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil // no lyrics found
+	} else if err != nil {
+		return err
+	}
+
+	// a sidecar of the other kind would be stale by now
+	synced := lyrics.IsSynced(data)
+	if err := os.Remove(track.Path().Sidecar(!synced)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	// as readable as the tracks themselves, for players to load them
+	return os.WriteFile(track.Path().Sidecar(synced), data, 0o644)
 }
 
 // mixer wraps playlists to their final destination
@@ -523,7 +1123,7 @@ func routineMix(encoding string) func(context.Context, chan error) {
 			}
 
 			for _, track := range playlist.Tracks {
-				if trackStatus, ok := indexData.Get(track); !ok || (trackStatus != index.Installed && trackStatus != index.Offline) {
+				if !routineMixable(track) {
 					continue
 				}
 
@@ -544,3 +1144,147 @@ func routineMix(encoding string) func(context.Context, chan error) {
 		tui.Lot("mix").Close(fmt.Sprintf("%d playlists", counter))
 	}
 }
+
+// only tracks which are available locally (or planned
+// to be, in dry-run mode) can be mixed into playlists
+func routineMixable(track *entity.Track) bool {
+	status, ok := indexData.Get(track)
+	return ok && (status == index.Installed || status == index.Offline) || planned.has(track.ID)
+}
+
+// planner stands in for the collector, postprocessor, installer
+// and mixer in dry-run mode, reporting which playlists would change
+func routinePlan(encoding string) func(context.Context, chan error) {
+	return func(_ context.Context, ch chan error) {
+		// decider does not pass anything over in dry-run mode,
+		// draining its queue only means waiting for it to be done
+		for range routineQueues[routineTypeCollect] {
+		}
+
+		counter := 0
+		for event := range routineQueues[routineTypeMix] {
+			playlist := event.(*playlist.Playlist)
+			encoder, err := playlist.Encoder(encoding)
+			if err != nil {
+				tui.AnchorPrintf("mixing failed for %s: %s", playlist.Name, err)
+				ch <- err
+				return
+			}
+
+			for _, track := range playlist.Tracks {
+				if !routineMixable(track) {
+					continue
+				}
+
+				if err := encoder.Add(track); err != nil {
+					tui.AnchorPrintf("adding track to %s failed: %s", playlist.Name, err)
+					ch <- err
+					return
+				}
+			}
+
+			if encoder.Changed() {
+				counter++
+				tui.Printf("write playlist %s to %s", playlist.Name, encoder.Target())
+			}
+		}
+		tui.Printf("plan: %d tracks to synchronize, %d playlists to write", planned.size(), counter)
+	}
+}
+
+// keeps track of the time spent by a track on a given stage
+func routineTrace(track *entity.Track, stage string, start time.Time) {
+	elapsed := time.Since(start).Milliseconds()
+	reportData.Update(track, func(entry *report.Entry) { entry.Durations[stage] = elapsed })
+}
+
+// statuses can only be settled once
+// every track went through the whole pipeline
+func saveReport(path string) error {
+	for _, entry := range reportData.Entries() {
+		if status, ok := indexData.Get(entry.Track); ok {
+			reportData.Update(entry.Track, func(entry *report.Entry) { entry.Status = index.StatusName(status) })
+		}
+	}
+	return reportData.Save(path)
+}
+
+// pruner removes indexed tracks which are not referenced
+// by any of the fetched collections, after confirmation
+func routinePrune(trash string, retain, strays, dryRun bool) error {
+	retained := map[string]bool{}
+	if retain {
+		var err error
+		if retained, err = playlist.References("."); err != nil {
+			return err
+		}
+	}
+
+	var (
+		orphans  []string
+		pruned   = map[string]bool{}
+		sidecars []string
+	)
+	for id, path := range indexData.Files() {
+		if !references.has(id) && !retained[filepath.Clean(path)] {
+			orphans = append(orphans, path)
+			pruned[path] = true
+		}
+	}
+	// sidecars go along with their tracks, while the ones beside no track are
+	// only offered if sidecars are being written, as a .txt or .lrc file alone
+	// may well be none of ours otherwise
+	for path, track := range indexData.Sidecars() {
+		if pruned[track] || (strays && len(track) == 0) {
+			sidecars = append(sidecars, path)
+		}
+	}
+	if len(orphans)+len(sidecars) == 0 {
+		return nil
+	}
+	sort.Strings(orphans)
+	sort.Strings(sidecars)
+
+	if len(orphans) > 0 {
+		tui.Printf("%d tracks are not referenced by any synchronized collection:", len(orphans))
+		for _, path := range orphans {
+			tui.Printf("prune %s", path)
+		}
+	}
+	if len(sidecars) > 0 {
+		tui.Printf("%d lyrics sidecars belong to no track or to a pruned one:", len(sidecars))
+		for _, path := range sidecars {
+			tui.Printf("prune %s", path)
+		}
+	}
+	if dryRun {
+		return nil
+	}
+	if answer := tui.Reads("proceed? [y/N]"); !strings.EqualFold(answer, "y") {
+		tui.Printf("prune aborted")
+		return nil
+	}
+
+	for _, path := range append(orphans, sidecars...) {
+		var err error
+		if len(trash) > 0 {
+			err = routinePruneTrash(path, trash)
+		} else {
+			err = os.Remove(path)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	tui.Printf("pruned %d tracks and %d lyrics sidecars", len(orphans), len(sidecars))
+	return nil
+}
+
+// trashed tracks keep their layout, relative to the output folder
+func routinePruneTrash(path, trash string) error {
+	target := filepath.Join(trash, path)
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	return sys.FileMoveOrCopy(path, target, true)
+}
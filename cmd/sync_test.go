@@ -2,10 +2,17 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/bogem/id3v2/v2"
 	"github.com/bytedance/mockey"
@@ -13,7 +20,9 @@ import (
 	"github.com/streambinder/spotitube/entity"
 	"github.com/streambinder/spotitube/entity/id3"
 	"github.com/streambinder/spotitube/entity/index"
+	"github.com/streambinder/spotitube/entity/journal"
 	"github.com/streambinder/spotitube/entity/playlist"
+	"github.com/streambinder/spotitube/entity/report"
 	"github.com/streambinder/spotitube/lyrics"
 	"github.com/streambinder/spotitube/processor"
 	"github.com/streambinder/spotitube/provider"
@@ -30,8 +39,25 @@ func BenchmarkSync(b *testing.B) {
 	}
 }
 
+func init() {
+	// keep tests away from the actual retry journal
+	journalPath = filepath.Join(os.TempDir(), "spotitube-test-"+journal.Basename)
+	indexPath = filepath.Join(os.TempDir(), "spotitube-test-"+index.StoreBasename)
+	snapshotsPath = filepath.Join(os.TempDir(), "spotitube-test-"+playlist.SnapshotsBasename)
+}
+
 func cleanup() {
 	indexData = index.New()
+	journalData = journal.New()
+	snapshotsData = playlist.NewSnapshots()
+	failures = &trackFailures{}
+	reportData = report.New()
+	sys.ErrSuppress(entity.SetFormat(entity.FormatMP3))
+	sys.ErrSuppress(entity.SetLayout(entity.LayoutFlat))
+	id3.SetSynchronizedLyrics(false)
+	sys.ErrSuppress(os.Remove(journalPath))
+	sys.ErrSuppress(os.Remove(indexPath))
+	sys.ErrSuppress(os.Remove(snapshotsPath))
 }
 
 func cloneTrack(track *entity.Track) *entity.Track {
@@ -67,10 +93,23 @@ func TestCmdSync(t *testing.T) {
 		ch[0] <- cloneTrack(_trackNotFound) // to skip inclusion in playlist
 		return _playlist, nil
 	}).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Playlists")).Return([]*playlist.Playlist{
+		{ID: "1", Name: "Keep"}, {ID: "2", Name: "Keep Not"}, {ID: "3", Name: "Other"},
+	}, nil).Build()
 	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Album")).To(func(_ string, ch ...chan interface{}) (*entity.Album, error) {
 		ch[0] <- cloneTrack(_track)
 		return _album, nil
 	}).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "SavedAlbums")).To(func(ch ...chan interface{}) error {
+		ch[0] <- cloneTrack(_track)
+		return nil
+	}).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Artist")).To(func(_ string, filter spotify.ArtistFilter, ch ...chan interface{}) error {
+		assert.Equal(t, []string{"album", "compilation"}, filter.AlbumTypes)
+		assert.Equal(t, "IT", filter.Market)
+		ch[0] <- cloneTrack(_track)
+		return nil
+	}).Build()
 	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Track")).To(func(_ string, ch ...chan interface{}) (*entity.Track, error) {
 		ch[0] <- cloneTrack(_track)
 		return _track, nil
@@ -90,7 +129,7 @@ func TestCmdSync(t *testing.T) {
 		}
 		return nil
 	}).Build()
-	mockey.Mock(lyrics.Search).Return("lyrics", nil).Build()
+	mockey.Mock(lyrics.SearchWithSource).Return("lyrics", "lrclib", nil).Build()
 	mockey.Mock(processor.Do).Return(nil).Build()
 	mockey.Mock(sys.FileMoveOrCopy).Return(nil).Build()
 	mockey.Mock(mockey.GetMethod(&playlist.M3UEncoder{}, "Close")).Return(nil).Build()
@@ -101,7 +140,14 @@ func TestCmdSync(t *testing.T) {
 	library, err := cmd.Flags().GetBool("library")
 	assert.Nil(t, err)
 	assert.True(t, library)
-	assert.Nil(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "-l", "-p", "123", "-a", "123", "-t", "123", "-f", "path")))
+	assert.Nil(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "-l", "-p", "123", "-a", "123", "-t", "123", "-f", "path",
+		"--artist", "123", "--artist-album-types", "album,compilation", "--artist-market", "IT")))
+	cmd = cmdSync()
+	assert.Nil(t, sys.ErrOnly(testExecute(cmd, "--plain", "--saved-albums", "--all-playlists",
+		"--playlists-include", "Keep*", "--playlists-exclude", "* Not")))
+	library, err = cmd.Flags().GetBool("library")
+	assert.Nil(t, err)
+	assert.False(t, library)
 }
 
 func TestCmdSyncInvalidEnvironment(t *testing.T) {
@@ -131,6 +177,7 @@ func TestCmdSyncOfflineIndex(t *testing.T) {
 		}
 		return nil
 	}).Build()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "Skipped")).Return(map[string]error{"Artist - Broken.mp3": errors.New("ko")}).Build()
 	mockey.Mock(spotify.Authenticate).Return(&spotify.Client{}, nil).Build()
 	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Library")).To(func(_ int, ch ...chan interface{}) error {
 		ch[0] <- cloneTrack(_track)
@@ -143,7 +190,7 @@ func TestCmdSyncOfflineIndex(t *testing.T) {
 		}
 		return nil
 	}).Build()
-	mockey.Mock(lyrics.Search).Return("lyrics", nil).Build()
+	mockey.Mock(lyrics.SearchWithSource).Return("lyrics", "lrclib", nil).Build()
 	mockey.Mock(processor.Do).Return(nil).Build()
 	mockey.Mock(sys.FileMoveOrCopy).Return(nil).Build()
 	mockey.Mock(mockey.GetMethod(&playlist.M3UEncoder{}, "Close")).Return(nil).Build()
@@ -276,6 +323,67 @@ func TestCmdSyncAlbumFailure(t *testing.T) {
 	assert.EqualError(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "-a", "123")), "ko")
 }
 
+func TestCmdSyncArtistFailure(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(cmd.ValidateEnvironment).Return(nil).Build()
+	mockey.Mock(cmd.Open).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "BuildWithProgress")).Return(nil).Build()
+	mockey.Mock(spotify.Authenticate).Return(&spotify.Client{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Artist")).Return(errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "--artist", "123")), "ko")
+}
+
+func TestCmdSyncSavedAlbumsFailure(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(cmd.ValidateEnvironment).Return(nil).Build()
+	mockey.Mock(cmd.Open).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "BuildWithProgress")).Return(nil).Build()
+	mockey.Mock(spotify.Authenticate).Return(&spotify.Client{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "SavedAlbums")).Return(errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "--saved-albums")), "ko")
+}
+
+func TestCmdSyncAllPlaylistsFailure(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(cmd.ValidateEnvironment).Return(nil).Build()
+	mockey.Mock(cmd.Open).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "BuildWithProgress")).Return(nil).Build()
+	mockey.Mock(spotify.Authenticate).Return(&spotify.Client{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Playlists")).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "--all-playlists")), "ko")
+}
+
+func TestCmdSyncAllPlaylistsBadPattern(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(cmd.ValidateEnvironment).Return(nil).Build()
+	mockey.Mock(cmd.Open).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "BuildWithProgress")).Return(nil).Build()
+	mockey.Mock(spotify.Authenticate).Return(&spotify.Client{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Playlists")).Return([]*playlist.Playlist{{ID: "1", Name: "Keep"}}, nil).Build()
+
+	// testing
+	assert.ErrorIs(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "--all-playlists", "--playlists-include", "[")), path.ErrBadPattern)
+	assert.ErrorIs(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "--all-playlists", "--playlists-exclude", "[")), path.ErrBadPattern)
+}
+
 func TestCmdSyncTrackFailure(t *testing.T) {
 	t.Cleanup(cleanup)
 
@@ -357,6 +465,7 @@ func TestCmdSyncDecideManual(t *testing.T) {
 
 	// testing
 	assert.Nil(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "--manual")))
+	assert.Equal(t, "skipped: no URL given", reportData.Entries()[0].Error)
 }
 
 func TestCmdSyncDecideFailure(t *testing.T) {
@@ -436,6 +545,10 @@ func TestCmdSyncDecideNotFound(t *testing.T) {
 
 	// testing
 	assert.Nil(t, sys.ErrOnly(testExecute(cmdSync(), "--plain")))
+	loaded, err := journal.Load(journalPath)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, loaded.Size())
+	assert.Equal(t, "not found", loaded.Entries()[0].Reason)
 }
 
 func TestCmdSyncCollectFailure(t *testing.T) {
@@ -465,10 +578,10 @@ func TestCmdSyncCollectFailure(t *testing.T) {
 		}
 		return nil
 	}).Build()
-	mockey.Mock(lyrics.Search).Return("", nil).Build()
+	mockey.Mock(lyrics.SearchWithSource).Return("", "", nil).Build()
 
 	// testing
-	assert.EqualError(t, sys.ErrOnly(testExecute(cmdSync(), "--plain")), "ko")
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdSync(), "--plain")), "1 tracks failed to synchronize")
 }
 
 func TestCmdSyncDownloadFailure(t *testing.T) {
@@ -495,10 +608,10 @@ func TestCmdSyncDownloadFailure(t *testing.T) {
 		}
 		return errors.New("ko")
 	}).Build()
-	mockey.Mock(lyrics.Search).Return("", nil).Build()
+	mockey.Mock(lyrics.SearchWithSource).Return("", "", nil).Build()
 
 	// testing
-	assert.EqualError(t, sys.ErrOnly(testExecute(cmdSync(), "--plain")), "ko")
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdSync(), "--plain")), "1 tracks failed to synchronize")
 }
 
 func TestCmdSyncLyricsFailure(t *testing.T) {
@@ -523,10 +636,10 @@ func TestCmdSyncLyricsFailure(t *testing.T) {
 		}
 		return nil
 	}).Build()
-	mockey.Mock(lyrics.Search).Return("", errors.New("ko")).Build()
+	mockey.Mock(lyrics.SearchWithSource).Return("", "", errors.New("ko")).Build()
 
 	// testing
-	assert.EqualError(t, sys.ErrOnly(testExecute(cmdSync(), "--plain")), "ko")
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdSync(), "--plain")), "1 tracks failed to synchronize")
 }
 
 func TestCmdSyncProcessorFailure(t *testing.T) {
@@ -551,11 +664,11 @@ func TestCmdSyncProcessorFailure(t *testing.T) {
 		}
 		return nil
 	}).Build()
-	mockey.Mock(lyrics.Search).Return("lyrics", nil).Build()
+	mockey.Mock(lyrics.SearchWithSource).Return("lyrics", "lrclib", nil).Build()
 	mockey.Mock(processor.Do).Return(errors.New("ko")).Build()
 
 	// testing
-	assert.EqualError(t, sys.ErrOnly(testExecute(cmdSync(), "--plain")), "ko")
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdSync(), "--plain")), "1 tracks failed to synchronize")
 }
 
 func TestCmdSyncInstallerFailure(t *testing.T) {
@@ -580,12 +693,301 @@ func TestCmdSyncInstallerFailure(t *testing.T) {
 		}
 		return nil
 	}).Build()
-	mockey.Mock(lyrics.Search).Return("lyrics", nil).Build()
+	mockey.Mock(lyrics.SearchWithSource).Return("lyrics", "lrclib", nil).Build()
 	mockey.Mock(processor.Do).Return(nil).Build()
 	mockey.Mock(sys.FileMoveOrCopy).Return(errors.New("ko")).Build()
 
 	// testing
-	assert.EqualError(t, sys.ErrOnly(testExecute(cmdSync(), "--plain")), "ko")
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdSync(), "--plain")), "1 tracks failed to synchronize")
+}
+
+func TestCmdSyncLayout(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	var (
+		_track = &entity.Track{ID: "TestCmdSyncLayout", Title: "Title", Artists: []string{"Artist"}, Album: "Album", Number: 1}
+		path   = t.TempDir()
+		broken = t.TempDir()
+	)
+	assert.Nil(t, os.WriteFile(filepath.Join(broken, "Artist"), []byte{}, 0o600))
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(cmd.ValidateEnvironment).Return(nil).Build()
+	mockey.Mock(cmd.Open).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "BuildWithProgress")).Return(nil).Build()
+	mockey.Mock(spotify.Authenticate).Return(&spotify.Client{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Library")).To(func(_ int, ch ...chan interface{}) error {
+		ch[0] <- cloneTrack(_track)
+		return nil
+	}).Build()
+	mockey.Mock(provider.Search).Return([]*provider.Match{{URL: "http://localhost/", Score: 0}}, nil).Build()
+	mockey.Mock(downloader.Download).To(func(_, _ string, _ processor.Processor, ch ...chan []byte) error {
+		for _, c := range ch {
+			c <- []byte{}
+		}
+		return nil
+	}).Build()
+	mockey.Mock(lyrics.SearchWithSource).Return("lyrics", "lrclib", nil).Build()
+	mockey.Mock(processor.Do).Return(nil).Build()
+	mockey.Mock(sys.FileMoveOrCopy).Return(nil).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "--layout", "wut")), "unsupported layout: wut")
+	assert.Nil(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "-o", path, "--layout", "nested")))
+	assert.DirExists(t, filepath.Join(path, "Artist", "Album"))
+	indexData = index.New()
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "-o", broken, "--layout", "nested")), "1 tracks failed to synchronize")
+}
+
+func TestCmdSyncFormat(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	var (
+		_track    = &entity.Track{ID: "TestCmdSyncFormat", Title: "Title", Artists: []string{"Artist"}, Album: "Album"}
+		installed []string
+	)
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(cmd.ValidateEnvironment).Return(nil).Build()
+	mockey.Mock(cmd.Open).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "BuildWithProgress")).Return(nil).Build()
+	mockey.Mock(spotify.Authenticate).Return(&spotify.Client{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Library")).To(func(_ int, ch ...chan interface{}) error {
+		ch[0] <- cloneTrack(_track)
+		return nil
+	}).Build()
+	mockey.Mock(provider.Search).Return([]*provider.Match{{URL: "http://localhost/", Score: 0}}, nil).Build()
+	mockey.Mock(downloader.Download).To(func(_, _ string, _ processor.Processor, ch ...chan []byte) error {
+		for _, c := range ch {
+			c <- []byte{}
+		}
+		return nil
+	}).Build()
+	mockey.Mock(lyrics.SearchWithSource).Return("lyrics", "lrclib", nil).Build()
+	mockey.Mock(processor.Do).Return(nil).Build()
+	mockey.Mock(sys.FileMoveOrCopy).To(func(src, dst string, _ ...bool) error {
+		installed = append(installed, filepath.Base(src), filepath.Base(dst))
+		return nil
+	}).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "--format", "wav")), "unsupported format: wav")
+	assert.Nil(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "-o", t.TempDir(), "--format", "flac")))
+	assert.Equal(t, []string{"testcmdsyncformat.flac", "Artist - Title.flac"}, installed)
+}
+
+func TestCmdSyncLyricsSYLT(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(cmd.ValidateEnvironment).Return(nil).Build()
+
+	// testing
+	plain := func() string {
+		tag := &id3.Tag{Tag: *id3v2.NewEmptyTag(), Cache: map[string]string{}}
+		tag.SetLyrics("Title", "[00:27.37]lyrics")
+		return tag.UnsynchronizedLyrics()
+	}
+	path := filepath.Join(t.TempDir(), "missing")
+	assert.NotNil(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "-o", path, "--lyrics-sylt")))
+	assert.Equal(t, "lyrics", plain())
+	assert.NotNil(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "-o", path)))
+	assert.Equal(t, "[00:27.37]lyrics", plain())
+}
+
+func TestCmdSyncLyricsSidecar(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	var (
+		_track = &entity.Track{ID: "TestCmdSyncLyricsSidecar", Title: "Title", Artists: []string{"Artist"}}
+		path   = t.TempDir()
+		cache  = filepath.Join(t.TempDir(), "lyrics.txt")
+	)
+	assert.Nil(t, os.WriteFile(cache, []byte("[00:27.37]lyrics"), 0o600))
+	assert.Nil(t, os.WriteFile(filepath.Join(path, "Artist - Title.txt"), []byte("stale"), 0o600))
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(cmd.ValidateEnvironment).Return(nil).Build()
+	mockey.Mock(cmd.Open).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "BuildWithProgress")).Return(nil).Build()
+	mockey.Mock(spotify.Authenticate).Return(&spotify.Client{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Library")).To(func(_ int, ch ...chan interface{}) error {
+		ch[0] <- cloneTrack(_track)
+		return nil
+	}).Build()
+	mockey.Mock(provider.Search).Return([]*provider.Match{{URL: "http://localhost/", Score: 0}}, nil).Build()
+	mockey.Mock(downloader.Download).To(func(_, _ string, _ processor.Processor, ch ...chan []byte) error {
+		for _, c := range ch {
+			c <- []byte{}
+		}
+		return nil
+	}).Build()
+	mockey.Mock(lyrics.SearchWithSource).Return("[00:27.37]lyrics", "lrclib", nil).Build()
+	mockey.Mock(processor.Do).Return(nil).Build()
+	mockey.Mock(sys.FileMoveOrCopy).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(entity.TrackPath{}, "Lyrics")).To(func() string { return cache }).Build()
+
+	// testing
+	assert.Nil(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "-o", path)))
+	assert.FileExists(t, filepath.Join(path, "Artist - Title.txt"))
+	assert.NoFileExists(t, filepath.Join(path, "Artist - Title.lrc"))
+	indexData = index.New()
+	assert.Nil(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "-o", path, "--lyrics-sidecar")))
+	assert.NoFileExists(t, filepath.Join(path, "Artist - Title.txt"))
+	data, err := os.ReadFile(filepath.Join(path, "Artist - Title.lrc"))
+	assert.Nil(t, err)
+	assert.Equal(t, "[00:27.37]lyrics", string(data))
+	info, err := os.Stat(filepath.Join(path, "Artist - Title.lrc"))
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0o644), info.Mode().Perm())
+
+	// no lyrics found, nothing to write
+	assert.Nil(t, os.Remove(cache))
+	assert.Nil(t, os.Remove(filepath.Join(path, "Artist - Title.lrc")))
+	indexData = index.New()
+	assert.Nil(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "-o", path, "--lyrics-sidecar")))
+	assert.NoFileExists(t, filepath.Join(path, "Artist - Title.lrc"))
+}
+
+func TestCmdSyncLyricsSidecarFailure(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	var (
+		_track = &entity.Track{ID: "TestCmdSyncLyricsSidecarFailure", Title: "Title", Artists: []string{"Artist"}}
+		path   = t.TempDir()
+		cache  = filepath.Join(t.TempDir(), "lyrics.txt")
+	)
+	assert.Nil(t, os.MkdirAll(cache, 0o755))
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(cmd.ValidateEnvironment).Return(nil).Build()
+	mockey.Mock(cmd.Open).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "BuildWithProgress")).Return(nil).Build()
+	mockey.Mock(spotify.Authenticate).Return(&spotify.Client{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Library")).To(func(_ int, ch ...chan interface{}) error {
+		ch[0] <- cloneTrack(_track)
+		return nil
+	}).Build()
+	mockey.Mock(provider.Search).Return([]*provider.Match{{URL: "http://localhost/", Score: 0}}, nil).Build()
+	mockey.Mock(downloader.Download).To(func(_, _ string, _ processor.Processor, ch ...chan []byte) error {
+		for _, c := range ch {
+			c <- []byte{}
+		}
+		return nil
+	}).Build()
+	mockey.Mock(lyrics.SearchWithSource).Return("lyrics", "lrclib", nil).Build()
+	mockey.Mock(processor.Do).Return(nil).Build()
+	mockey.Mock(sys.FileMoveOrCopy).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(entity.TrackPath{}, "Lyrics")).To(func() string { return cache }).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "-o", path, "--lyrics-sidecar")), "1 tracks failed to synchronize")
+	assert.Nil(t, os.Remove(cache))
+	assert.Nil(t, os.WriteFile(cache, []byte("lyrics"), 0o600))
+	assert.Nil(t, os.MkdirAll(filepath.Join(path, "Artist - Title.lrc", "busy"), 0o755))
+	indexData = index.New()
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "-o", path, "--lyrics-sidecar")), "1 tracks failed to synchronize")
+}
+
+func TestCmdSyncPathTemplate(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	var (
+		_track      = &entity.Track{ID: "TestCmdSyncPathTemplate", Title: "Title", Artists: []string{"Artist"}, Album: "Album"}
+		_trackTwin  = &entity.Track{ID: "TestCmdSyncPathTemplateTwin", Title: "Title", Artists: []string{"Artist"}, Album: "Single"}
+		path        = t.TempDir()
+		installed   []string
+		installLock sync.Mutex
+	)
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(cmd.ValidateEnvironment).Return(nil).Build()
+	mockey.Mock(cmd.Open).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "BuildWithProgress")).Return(nil).Build()
+	mockey.Mock(spotify.Authenticate).Return(&spotify.Client{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Library")).To(func(_ int, ch ...chan interface{}) error {
+		ch[0] <- cloneTrack(_track)
+		ch[0] <- cloneTrack(_trackTwin)
+		return nil
+	}).Build()
+	mockey.Mock(provider.Search).Return([]*provider.Match{{URL: "http://localhost/", Score: 0}}, nil).Build()
+	mockey.Mock(downloader.Download).To(func(_, _ string, _ processor.Processor, ch ...chan []byte) error {
+		for _, c := range ch {
+			c <- []byte{}
+		}
+		return nil
+	}).Build()
+	mockey.Mock(lyrics.SearchWithSource).Return("lyrics", "lrclib", nil).Build()
+	mockey.Mock(processor.Do).Return(nil).Build()
+	mockey.Mock(sys.FileMoveOrCopy).To(func(_, destination string, _ ...bool) error {
+		installLock.Lock()
+		defer installLock.Unlock()
+		installed = append(installed, destination)
+		return nil
+	}).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "--path-template", "{wut}")), "unknown path template field: wut")
+	assert.Nil(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "-o", path, "--path-template", "{artist}/{title}")))
+	assert.Equal(t, []string{filepath.Join("Artist", "Title.mp3")}, installed)
+	_, ok := indexData.Get(_trackTwin)
+	assert.True(t, ok)
+}
+
+func TestCmdSyncWorkers(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	var (
+		_tracks        []*entity.Track
+		downloaded     = map[string]int{}
+		downloadedLock sync.Mutex
+	)
+	for i := range 16 {
+		_tracks = append(_tracks, &entity.Track{ID: fmt.Sprintf("TestCmdSyncWorkers%d", i), Title: fmt.Sprintf("Title %d", i), Artists: []string{"Artist"}})
+	}
+	_playlist := &playlist.Playlist{Name: "Playlist", Tracks: _tracks}
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(cmd.ValidateEnvironment).Return(nil).Build()
+	mockey.Mock(cmd.Open).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "BuildWithProgress")).Return(nil).Build()
+	mockey.Mock(spotify.Authenticate).Return(&spotify.Client{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Playlist")).To(func(_ string, ch ...chan interface{}) (*playlist.Playlist, error) {
+		// every track is fetched twice, to trigger duplicate checks across workers
+		for _, track := range append(_tracks, _tracks...) {
+			ch[0] <- cloneTrack(track)
+		}
+		return _playlist, nil
+	}).Build()
+	mockey.Mock(provider.Search).Return([]*provider.Match{{URL: "http://localhost/", Score: 0}}, nil).Build()
+	mockey.Mock(downloader.Download).To(func(url, path string, _ processor.Processor, ch ...chan []byte) error {
+		downloadedLock.Lock()
+		downloaded[path]++
+		downloadedLock.Unlock()
+		for _, c := range ch {
+			c <- []byte{}
+		}
+		return nil
+	}).Build()
+	mockey.Mock(lyrics.SearchWithSource).Return("lyrics", "lrclib", nil).Build()
+	mockey.Mock(processor.Do).Return(nil).Build()
+	mockey.Mock(sys.FileMoveOrCopy).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&playlist.M3UEncoder{}, "Close")).Return(nil).Build()
+
+	// testing
+	assert.Nil(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "-p", "123",
+		"--search-workers", "4", "--download-workers", "4", "--process-workers", "4")))
+	assert.Equal(t, len(_tracks), indexData.Size(index.Installed))
+	for _, track := range _tracks {
+		assert.Equal(t, 1, downloaded[track.Path().Download()])
+	}
 }
 
 func TestCmdSyncPlaylistEncoderFailure(t *testing.T) {
@@ -609,7 +1011,7 @@ func TestCmdSyncPlaylistEncoderFailure(t *testing.T) {
 		}
 		return nil
 	}).Build()
-	mockey.Mock(lyrics.Search).Return("lyrics", nil).Build()
+	mockey.Mock(lyrics.SearchWithSource).Return("lyrics", "lrclib", nil).Build()
 	mockey.Mock(processor.Do).Return(nil).Build()
 	mockey.Mock(sys.FileMoveOrCopy).Return(nil).Build()
 	mockey.Mock(mockey.GetMethod(playlist.Playlist{}, "Encoder")).Return(nil, errors.New("ko")).Build()
@@ -642,7 +1044,7 @@ func TestCmdSyncPlaylistEncoderAddFailure(t *testing.T) {
 		}
 		return nil
 	}).Build()
-	mockey.Mock(lyrics.Search).Return("lyrics", nil).Build()
+	mockey.Mock(lyrics.SearchWithSource).Return("lyrics", "lrclib", nil).Build()
 	mockey.Mock(processor.Do).Return(nil).Build()
 	mockey.Mock(sys.FileMoveOrCopy).Return(nil).Build()
 	mockey.Mock(mockey.GetMethod(&playlist.M3UEncoder{}, "Add")).Return(errors.New("ko")).Build()
@@ -675,7 +1077,7 @@ func TestCmdSyncPlaylistEncoderCloseFailure(t *testing.T) {
 		}
 		return nil
 	}).Build()
-	mockey.Mock(lyrics.Search).Return("lyrics", nil).Build()
+	mockey.Mock(lyrics.SearchWithSource).Return("lyrics", "lrclib", nil).Build()
 	mockey.Mock(processor.Do).Return(nil).Build()
 	mockey.Mock(sys.FileMoveOrCopy).Return(nil).Build()
 	mockey.Mock(mockey.GetMethod(&playlist.M3UEncoder{}, "Close")).Return(errors.New("ko")).Build()
@@ -683,3 +1085,711 @@ func TestCmdSyncPlaylistEncoderCloseFailure(t *testing.T) {
 	// testing
 	assert.EqualError(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "-p", "123")), "ko")
 }
+
+func TestCmdSyncFailureIsolation(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	var (
+		_track       = &entity.Track{ID: "TestCmdSyncFailureIsolation", Title: "Title", Artists: []string{"Artist"}}
+		_trackFailed = &entity.Track{ID: "TestCmdSyncFailureIsolationFailed", Title: "Title Failed", Artists: []string{"Artist"}}
+	)
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(cmd.ValidateEnvironment).Return(nil).Build()
+	mockey.Mock(cmd.Open).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "BuildWithProgress")).Return(nil).Build()
+	mockey.Mock(spotify.Authenticate).Return(&spotify.Client{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Library")).To(func(_ int, ch ...chan interface{}) error {
+		ch[0] <- cloneTrack(_trackFailed)
+		ch[0] <- cloneTrack(_track)
+		return nil
+	}).Build()
+	mockey.Mock(provider.Search).Return([]*provider.Match{{URL: "http://localhost/", Score: 0}}, nil).Build()
+	mockey.Mock(downloader.Download).To(func(_, _ string, _ processor.Processor, ch ...chan []byte) error {
+		for _, c := range ch {
+			c <- []byte{}
+		}
+		return nil
+	}).Build()
+	mockey.Mock(lyrics.SearchWithSource).To(func(track *entity.Track) (string, string, error) {
+		if track.ID == _trackFailed.ID {
+			return "", "", errors.New("ko")
+		}
+		return "lyrics", "lrclib", nil
+	}).Build()
+	mockey.Mock(processor.Do).Return(nil).Build()
+	mockey.Mock(sys.FileMoveOrCopy).Return(nil).Build()
+
+	// testing
+	reportPath := filepath.Join(t.TempDir(), "report.json")
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "--report", reportPath)), "1 tracks failed to synchronize")
+	status, ok := indexData.Get(_track)
+	assert.True(t, ok)
+	assert.Equal(t, index.Installed, status)
+	status, ok = indexData.Get(_trackFailed)
+	assert.True(t, ok)
+	assert.Equal(t, index.Online, status)
+	assert.Len(t, failures.list(), 1)
+	assert.Equal(t, "collect", failures.list()[0].stage)
+
+	var entries []*report.Entry
+	data, err := os.ReadFile(reportPath)
+	assert.Nil(t, err)
+	assert.Nil(t, json.Unmarshal(data, &entries))
+	assert.Len(t, entries, 2)
+	for _, entry := range entries {
+		assert.Equal(t, "http://localhost/", entry.UpstreamURL)
+		assert.Contains(t, entry.Durations, "decide")
+		switch entry.ID {
+		case _track.ID:
+			assert.Equal(t, "installed", entry.Status)
+			assert.Equal(t, "lrclib", entry.LyricsSource)
+			assert.Empty(t, entry.Error)
+			assert.Contains(t, entry.Durations, "install")
+		case _trackFailed.ID:
+			assert.Equal(t, "online", entry.Status)
+			assert.Equal(t, "collect: ko", entry.Error)
+		default:
+			assert.Fail(t, "unexpected report entry", entry.ID)
+		}
+	}
+}
+
+func TestCmdSyncReportFailure(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(cmd.ValidateEnvironment).Return(nil).Build()
+	mockey.Mock(cmd.Open).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "BuildWithProgress")).Return(nil).Build()
+	mockey.Mock(spotify.Authenticate).Return(&spotify.Client{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Library")).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&report.Report{}, "Save")).Return(errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "--report", "report.json")), "ko")
+}
+
+func TestCmdSyncRetryFailed(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	var (
+		_track         = &entity.Track{ID: "TestCmdSyncRetryFailed", Title: "Title", Artists: []string{"Artist"}}
+		_trackGone     = &entity.Track{ID: "TestCmdSyncRetryFailedGone", Title: "Title Gone", Artists: []string{"Artist"}}
+		_trackNotDue   = &entity.Track{ID: "TestCmdSyncRetryFailedNotDue", Title: "Title Not Due", Artists: []string{"Artist"}}
+		lastAttempt    = time.Now().Add(-24 * time.Hour)
+		libraryFetched = false
+	)
+	data, err := json.Marshal([]*journal.Entry{
+		{ID: _track.ID, Title: _track.Title, Artist: _track.Artists[0], Attempts: 1, LastAttempt: lastAttempt},
+		{ID: _trackGone.ID, Title: _trackGone.Title, Artist: _trackGone.Artists[0], Attempts: 1, LastAttempt: lastAttempt},
+		{ID: _trackNotDue.ID, Title: _trackNotDue.Title, Artist: _trackNotDue.Artists[0], Attempts: 1, LastAttempt: time.Now()},
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, os.WriteFile(journalPath, data, 0o600))
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(cmd.ValidateEnvironment).Return(nil).Build()
+	mockey.Mock(cmd.Open).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "BuildWithProgress")).Return(nil).Build()
+	mockey.Mock(spotify.Authenticate).Return(&spotify.Client{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Library")).To(func(int, ...chan interface{}) error {
+		libraryFetched = true
+		return nil
+	}).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Track")).To(func(id string, ch ...chan interface{}) (*entity.Track, error) {
+		if id != _track.ID {
+			return nil, errors.New("ko")
+		}
+		for _, c := range ch {
+			c <- cloneTrack(_track)
+		}
+		return _track, nil
+	}).Build()
+	mockey.Mock(provider.Search).Return([]*provider.Match{{URL: "http://localhost/", Score: 0}}, nil).Build()
+	mockey.Mock(downloader.Download).To(func(_, _ string, _ processor.Processor, ch ...chan []byte) error {
+		for _, c := range ch {
+			c <- []byte{}
+		}
+		return nil
+	}).Build()
+	mockey.Mock(lyrics.SearchWithSource).Return("lyrics", "lrclib", nil).Build()
+	mockey.Mock(processor.Do).Return(nil).Build()
+	mockey.Mock(sys.FileMoveOrCopy).Return(nil).Build()
+
+	// testing
+	assert.Nil(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "--retry-failed")))
+	assert.False(t, libraryFetched)
+	loaded, err := journal.Load(journalPath)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, loaded.Size())
+	for _, entry := range loaded.Entries() {
+		assert.NotEqual(t, _track.ID, entry.ID)
+		if entry.ID == _trackGone.ID {
+			assert.Equal(t, 2, entry.Attempts)
+			assert.Equal(t, "fetch failed: ko", entry.Reason)
+		}
+	}
+}
+
+func TestCmdSyncJournalLoadFailure(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(cmd.ValidateEnvironment).Return(nil).Build()
+	mockey.Mock(journal.Load).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdSync(), "--plain")), "ko")
+}
+
+func TestCmdSyncJournalSaveFailure(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(cmd.ValidateEnvironment).Return(nil).Build()
+	mockey.Mock(cmd.Open).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "BuildWithProgress")).Return(nil).Build()
+	mockey.Mock(spotify.Authenticate).Return(&spotify.Client{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Library")).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&journal.Journal{}, "Save")).Return(errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdSync(), "--plain")), "ko")
+}
+
+func TestCmdSyncDryRun(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	var (
+		_track        = &entity.Track{ID: "TestCmdSyncDryRun", Title: "Title", Artists: []string{"Artist"}}
+		_trackOffline = &entity.Track{ID: "TestCmdSyncDryRunOffline", Title: "Title Offline", Artists: []string{"Artist"}}
+		_trackFix     = &entity.Track{ID: "123", Title: "Title Fix", Artists: []string{"Artist"}}
+		_trackMissing = &entity.Track{ID: "TestCmdSyncDryRunMissing", Title: "Title Missing", Artists: []string{"Artist"}}
+		_playlist     = &playlist.Playlist{Name: "Playlist", Tracks: []*entity.Track{_track, _trackOffline, _trackMissing}}
+		_unchanged    = &playlist.Playlist{Name: "Unchanged", Tracks: []*entity.Track{_trackOffline}}
+		changes       = 0
+	)
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(cmd.ValidateEnvironment).Return(nil).Build()
+	mockey.Mock(cmd.Open).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "BuildWithProgress")).To(func(data *index.Index, _ string, _ chan<- string, _ ...int) error {
+		data.Set(_trackOffline, index.Offline)
+		return nil
+	}).Build()
+	mockey.Mock(spotify.Authenticate).Return(&spotify.Client{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Playlist")).To(func(target string, ch ...chan interface{}) (*playlist.Playlist, error) {
+		if target == _unchanged.Name {
+			return _unchanged, nil
+		}
+		ch[0] <- cloneTrack(_track)
+		ch[0] <- cloneTrack(_trackOffline)
+		return _playlist, nil
+	}).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Track")).To(func(_ string, ch ...chan interface{}) (*entity.Track, error) {
+		ch[0] <- cloneTrack(_trackFix)
+		return _trackFix, nil
+	}).Build()
+	mockey.Mock(id3.Open).Return(&id3.Tag{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&id3.Tag{}, "userDefinedText")).Return(_trackFix.ID).Build()
+	mockey.Mock(mockey.GetMethod(&id3.Tag{}, "Close")).Return(nil).Build()
+	mockey.Mock(provider.Search).Return([]*provider.Match{{URL: "http://localhost/", Score: 10}}, nil).Build()
+	mockey.Mock(downloader.Download).To(func(string, string, processor.Processor, ...chan []byte) error {
+		t.Error("dry-run must not download")
+		return nil
+	}).Build()
+	mockey.Mock(mockey.GetMethod(&playlist.M3UEncoder{}, "Changed")).To(func(encoder *playlist.M3UEncoder) bool {
+		changes++
+		return encoder.Target() != "unchanged.m3u"
+	}).Build()
+	mockey.Mock(mockey.GetMethod(&playlist.M3UEncoder{}, "Close")).To(func(*playlist.M3UEncoder) error {
+		t.Error("dry-run must not write playlists")
+		return nil
+	}).Build()
+
+	// testing
+	assert.Nil(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "--dry-run", "-p", _playlist.Name, "-p", _unchanged.Name, "-f", "path")))
+	assert.Equal(t, 2, changes)
+	status, ok := indexData.Get(_track)
+	assert.True(t, ok)
+	assert.Equal(t, index.Online, status)
+	assert.True(t, planned.has(_track.ID))
+	assert.Equal(t, 2, planned.size())
+	_, err := os.Stat(journalPath)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestCmdSyncDryRunEncoderFailure(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(cmd.ValidateEnvironment).Return(nil).Build()
+	mockey.Mock(cmd.Open).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "BuildWithProgress")).Return(nil).Build()
+	mockey.Mock(spotify.Authenticate).Return(&spotify.Client{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Playlist")).Return(&playlist.Playlist{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(playlist.Playlist{}, "Encoder")).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "--dry-run", "-p", "123")), "ko")
+}
+
+func TestCmdSyncDryRunEncoderAddFailure(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	_playlist := &playlist.Playlist{Tracks: []*entity.Track{
+		{ID: "TestCmdSyncDryRunEncoderAddFailure", Title: "Title", Artists: []string{"Artist"}},
+	}}
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(cmd.ValidateEnvironment).Return(nil).Build()
+	mockey.Mock(cmd.Open).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "BuildWithProgress")).Return(nil).Build()
+	mockey.Mock(spotify.Authenticate).Return(&spotify.Client{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Playlist")).To(func(_ string, ch ...chan interface{}) (*playlist.Playlist, error) {
+		ch[0] <- _playlist.Tracks[0]
+		return _playlist, nil
+	}).Build()
+	mockey.Mock(provider.Search).Return([]*provider.Match{{URL: "http://localhost/", Score: 0}}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&playlist.M3UEncoder{}, "Add")).Return(errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "--dry-run", "-p", "123")), "ko")
+}
+
+func TestCmdSyncPrune(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	var (
+		_track  = &entity.Track{ID: "TestCmdSyncPrune", Title: "Title", Artists: []string{"Artist"}}
+		path    = t.TempDir()
+		trash   = filepath.Join(t.TempDir(), "trash")
+		kept    = filepath.Join(path, "Artist - Title.mp3")
+		orphan  = filepath.Join(path, "Artist - Orphan.mp3")
+		answer  = "n"
+		retains = map[string]bool{}
+	)
+	assert.Nil(t, os.WriteFile(kept, []byte{}, 0o600))
+	assert.Nil(t, os.WriteFile(orphan, []byte{}, 0o600))
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(cmd.ValidateEnvironment).Return(nil).Build()
+	mockey.Mock(cmd.Open).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "BuildWithProgress")).To(func(index *index.Index, _ string, _ chan<- string, _ ...int) error {
+		index.Set(_track, 0)
+		return nil
+	}).Build()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "Files")).Return(map[string]string{_track.ID: filepath.Base(kept), "orphan": filepath.Base(orphan)}).Build()
+	mockey.Mock(spotify.Authenticate).Return(&spotify.Client{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Library")).To(func(_ int, ch ...chan interface{}) error {
+		for _, c := range ch {
+			c <- cloneTrack(_track)
+		}
+		return nil
+	}).Build()
+	mockey.Mock(mockey.GetMethod(&anchor.Window{}, "Reads")).To(func(*anchor.Window, string, ...interface{}) string {
+		return answer
+	}).Build()
+	mockey.Mock(playlist.References).To(func(string) (map[string]bool, error) {
+		return retains, nil
+	}).Build()
+
+	// testing
+	assert.Nil(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "-o", path, "--prune", "--dry-run")))
+	assert.FileExists(t, orphan)
+	assert.Nil(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "-o", path, "--prune")))
+	assert.FileExists(t, orphan)
+	answer = "y"
+	retains[filepath.Base(orphan)] = true
+	assert.Nil(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "-o", path, "--prune", "--prune-retain")))
+	assert.FileExists(t, orphan)
+	assert.Nil(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "-o", path, "--prune", "--prune-trash", trash)))
+	assert.NoFileExists(t, orphan)
+	assert.FileExists(t, filepath.Join(trash, filepath.Base(orphan)))
+	assert.FileExists(t, kept)
+	assert.Nil(t, os.WriteFile(orphan, []byte{}, 0o600))
+	assert.Nil(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "-o", path, "--prune")))
+	assert.NoFileExists(t, orphan)
+	assert.FileExists(t, kept)
+}
+
+func TestCmdSyncPruneSidecars(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	var (
+		_track = &entity.Track{ID: "TestCmdSyncPruneSidecars", Title: "Title", Artists: []string{"Artist"}}
+		path   = t.TempDir()
+	)
+	for _, name := range []string{"Artist - Title.mp3", "Artist - Title.lrc", "Artist - Orphan.mp3", "Artist - Orphan.txt", "Stray.lrc"} {
+		assert.Nil(t, os.WriteFile(filepath.Join(path, name), []byte{}, 0o600))
+	}
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(cmd.ValidateEnvironment).Return(nil).Build()
+	mockey.Mock(cmd.Open).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "BuildWithProgress")).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "Files")).To(func() map[string]string {
+		files := map[string]string{_track.ID: "Artist - Title.mp3"}
+		if _, err := os.Stat(filepath.Join(path, "Artist - Orphan.mp3")); err == nil {
+			files["orphan"] = "Artist - Orphan.mp3"
+		}
+		return files
+	}).Build()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "Sidecars")).To(func() map[string]string {
+		sidecars := map[string]string{}
+		for _, name := range []string{"Artist - Title", "Artist - Orphan", "Stray"} {
+			for _, format := range []string{entity.SyncedFormat, entity.LyricsFormat} {
+				if _, err := os.Stat(filepath.Join(path, name+"."+format)); err == nil {
+					sidecars[name+"."+format] = sys.Ternary(name == "Stray", "", name+".mp3")
+				}
+			}
+		}
+		return sidecars
+	}).Build()
+	mockey.Mock(spotify.Authenticate).Return(&spotify.Client{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Library")).To(func(_ int, ch ...chan interface{}) error {
+		for _, c := range ch {
+			c <- cloneTrack(_track)
+		}
+		return nil
+	}).Build()
+	mockey.Mock(mockey.GetMethod(&anchor.Window{}, "Reads")).Return("y").Build()
+
+	// testing
+	assert.Nil(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "-o", path, "--prune")))
+	assert.NoFileExists(t, filepath.Join(path, "Artist - Orphan.mp3"))
+	assert.NoFileExists(t, filepath.Join(path, "Artist - Orphan.txt"))
+	assert.FileExists(t, filepath.Join(path, "Stray.lrc"))
+	assert.FileExists(t, filepath.Join(path, "Artist - Title.mp3"))
+	assert.FileExists(t, filepath.Join(path, "Artist - Title.lrc"))
+
+	// orphaned sidecars are only offered when writing sidecars
+	assert.Nil(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "-o", path, "--prune", "--lyrics-sidecar")))
+	assert.NoFileExists(t, filepath.Join(path, "Stray.lrc"))
+	assert.FileExists(t, filepath.Join(path, "Artist - Title.mp3"))
+	assert.FileExists(t, filepath.Join(path, "Artist - Title.lrc"))
+}
+
+func TestCmdSyncPruneSelection(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	// testing
+	for _, args := range [][]string{
+		{"--track", "123"},
+		{"--album", "123"},
+		{"--retry-failed"},
+		{"--fix", "track.mp3"},
+	} {
+		assert.ErrorContains(t, sys.ErrOnly(testExecute(cmdSync(), append([]string{"--plain", "--prune"}, args...)...)), "none of the others can be", args[0])
+	}
+}
+
+func TestCmdSyncPruneNothing(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(cmd.ValidateEnvironment).Return(nil).Build()
+	mockey.Mock(cmd.Open).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "BuildWithProgress")).Return(nil).Build()
+	mockey.Mock(spotify.Authenticate).Return(&spotify.Client{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Library")).Return(nil).Build()
+
+	// testing
+	assert.Nil(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "--prune")))
+}
+
+func TestCmdSyncPruneFailure(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(cmd.ValidateEnvironment).Return(nil).Build()
+	mockey.Mock(cmd.Open).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "BuildWithProgress")).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "Files")).Return(map[string]string{"orphan": "orphan.mp3"}).Build()
+	mockey.Mock(spotify.Authenticate).Return(&spotify.Client{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Library")).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&anchor.Window{}, "Reads")).Return("y").Build()
+	mockey.Mock(playlist.References).Return(nil, errors.New("ko references")).Build()
+	mockey.Mock(os.MkdirAll).Return(errors.New("ko mkdir")).Build()
+	mockey.Mock(os.Remove).Return(errors.New("ko remove")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "--prune", "--prune-retain")), "ko references")
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "--prune", "--prune-trash", "trash")), "ko mkdir")
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "--prune")), "ko remove")
+}
+
+func TestCmdSyncIndexStoreFailure(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(cmd.ValidateEnvironment).Return(nil).Build()
+	mockey.Mock(cmd.Open).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "Load")).Return(errors.New("ko load")).Build()
+	mockey.Mock(spotify.Authenticate).Return(&spotify.Client{}, nil).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdSync(), "--plain")), "ko load")
+}
+
+func TestCmdSyncIndexStoreSaveFailure(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(cmd.ValidateEnvironment).Return(nil).Build()
+	mockey.Mock(cmd.Open).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "BuildWithProgress")).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "Save")).Return(errors.New("ko save")).Build()
+	mockey.Mock(spotify.Authenticate).Return(&spotify.Client{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Library")).Return(nil).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdSync(), "--plain")), "ko save")
+}
+
+func TestCmdSyncWatch(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	var (
+		_track          = &entity.Track{ID: "TestCmdSyncWatch", Title: "Title", Artists: []string{"Artist"}}
+		cycles          = 0
+		authentications = 0
+		persistences    = 0
+		processes       = 0
+	)
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(cmd.ValidateEnvironment).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "BuildWithProgress")).Return(nil).Build()
+	mockey.Mock(spotify.Authenticate).To(func(func(string) error) (*spotify.Client, error) {
+		authentications++
+		return &spotify.Client{}, nil
+	}).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Username")).Return("alice", nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Persist")).To(func() error {
+		persistences++
+		return nil
+	}).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Library")).To(func(_ int, ch ...chan interface{}) error {
+		cycles++
+		switch cycles {
+		case 1:
+			return errors.New("ko")
+		case 3:
+			assert.Nil(t, syscall.Kill(os.Getpid(), syscall.SIGTERM))
+			time.Sleep(10 * time.Millisecond) // let the signal be delivered
+		}
+		for _, c := range ch {
+			c <- cloneTrack(_track)
+		}
+		return nil
+	}).Build()
+	mockey.Mock(provider.Search).Return([]*provider.Match{{URL: "http://localhost/", Score: 0}}, nil).Build()
+	mockey.Mock(downloader.Download).To(func(_, _ string, _ processor.Processor, ch ...chan []byte) error {
+		for _, c := range ch {
+			c <- []byte{}
+		}
+		return nil
+	}).Build()
+	mockey.Mock(lyrics.SearchWithSource).Return("", "", nil).Build()
+	mockey.Mock(processor.Do).To(func(interface{}) error {
+		// failing tracks are looked for again on the following cycle
+		processes++
+		if processes == 1 {
+			return errors.New("ko")
+		}
+		return nil
+	}).Build()
+	mockey.Mock(sys.FileMoveOrCopy).Return(nil).Build()
+
+	// testing
+	assert.NotNil(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "--watch", "50ms", "--prune")))
+	assert.Nil(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "--watch", "50ms")))
+	assert.Equal(t, 3, cycles)
+	assert.Equal(t, 1, authentications)
+	assert.Equal(t, 2, persistences)
+	assert.Equal(t, 2, processes)
+	status, ok := indexData.Get(_track)
+	assert.True(t, ok)
+	assert.Equal(t, index.Installed, status)
+}
+
+func TestCmdSyncWatchTerminated(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	// testing
+	assert.Nil(t, syncWatch(time.Hour, func() error {
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			sys.ErrSuppress(syscall.Kill(os.Getpid(), syscall.SIGTERM))
+		}()
+		return nil
+	}))
+}
+
+func TestRoutineAuthPersistFailure(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Persist")).Return(errors.New("ko")).Build()
+
+	// testing
+	tui.EnablePlainMode()
+	syncReset()
+	spotifyClient = &spotify.Client{}
+	defer func() { spotifyClient = nil }()
+	errChannel := make(chan error, 1)
+	routineAuth(context.Background(), errChannel)
+	assert.EqualError(t, <-errChannel, "ko")
+	assert.False(t, <-routineSemaphores[routineTypeAuth])
+}
+
+func TestCmdSyncSnapshot(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	var (
+		_track     = &entity.Track{ID: "TestCmdSyncSnapshot", Title: "Title", Artists: []string{"Artist"}}
+		_notFound  = &entity.Track{ID: "TestCmdSyncSnapshotNotFound", Title: "Title Not Found", Artists: []string{"Artist"}}
+		_collides  = &entity.Track{ID: "TestCmdSyncSnapshotCollides", Title: "Title", Artists: []string{"Artist"}}
+		_playlists = map[string]*playlist.Playlist{
+			"123": {ID: "123", Name: "Playlist", SnapshotID: "snapshot", Tracks: []*entity.Track{_track}},
+			"456": {ID: "456", Name: "Tracks", SnapshotID: "snapshot", Tracks: []*entity.Track{_track}},
+			"789": {ID: "789", Name: "Failing", SnapshotID: "snapshot", Tracks: []*entity.Track{_notFound}},
+			"012": {ID: "012", Name: "Colliding", SnapshotID: "snapshot", Tracks: []*entity.Track{_track, _collides}},
+		}
+		snapshot = "snapshot"
+		fetches  = 0
+		path     = t.TempDir()
+	)
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(cmd.ValidateEnvironment).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "BuildWithProgress")).Return(nil).Build()
+	mockey.Mock(spotify.Authenticate).Return(&spotify.Client{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Username")).Return("alice", nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "PlaylistSnapshot")).To(func(id string) (*playlist.Playlist, error) {
+		return &playlist.Playlist{ID: id, Name: _playlists[id].Name, SnapshotID: snapshot}, nil
+	}).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Playlist")).To(func(id string, ch ...chan interface{}) (*playlist.Playlist, error) {
+		fetches++
+		for _, track := range _playlists[id].Tracks {
+			for _, c := range ch {
+				c <- cloneTrack(track)
+			}
+		}
+		_playlists[id].SnapshotID = snapshot
+		return _playlists[id], nil
+	}).Build()
+	mockey.Mock(provider.Search).To(func(track *entity.Track) ([]*provider.Match, error) {
+		if track.ID == _notFound.ID {
+			return []*provider.Match{}, nil
+		}
+		return []*provider.Match{{URL: "http://localhost/", Score: 0}}, nil
+	}).Build()
+	mockey.Mock(downloader.Download).To(func(_, _ string, _ processor.Processor, ch ...chan []byte) error {
+		for _, c := range ch {
+			c <- []byte{}
+		}
+		return nil
+	}).Build()
+	mockey.Mock(lyrics.SearchWithSource).Return("", "", nil).Build()
+	mockey.Mock(processor.Do).Return(nil).Build()
+	mockey.Mock(sys.FileMoveOrCopy).Return(nil).Build()
+
+	// testing
+	args := []string{"--plain", "-o", path, "-p", "123", "-p", "789", "--playlist-tracks", "456", "-p", "012"}
+	assert.Nil(t, sys.ErrOnly(testExecute(cmdSync(), args...)))
+	assert.Equal(t, 4, fetches)
+	assert.Equal(t, "snapshot", snapshotsData.Get("123"))
+	assert.Equal(t, "snapshot", snapshotsData.Get("456"))
+	assert.Empty(t, snapshotsData.Get("789"))
+	assert.Empty(t, snapshotsData.Get("012"))
+	assert.FileExists(t, snapshotsPath)
+
+	// unchanged playlists are skipped, unlike the failing or skipping ones
+	assert.Nil(t, sys.ErrOnly(testExecute(cmdSync(), args...)))
+	assert.Equal(t, 6, fetches)
+	assert.Empty(t, snapshotsData.Get("789"))
+	assert.Empty(t, snapshotsData.Get("012"))
+
+	// unless forced
+	assert.Nil(t, sys.ErrOnly(testExecute(cmdSync(), append(args, "--force")...)))
+	assert.Equal(t, 10, fetches)
+
+	// or their file is gone
+	assert.Nil(t, os.Remove(filepath.Join(path, "playlist.m3u")))
+	assert.Nil(t, sys.ErrOnly(testExecute(cmdSync(), args...)))
+	assert.Equal(t, 13, fetches)
+	assert.FileExists(t, filepath.Join(path, "playlist.m3u"))
+
+	// or their contents changed
+	snapshot = "changed"
+	assert.Nil(t, sys.ErrOnly(testExecute(cmdSync(), args...)))
+	assert.Equal(t, 17, fetches)
+
+	// encoding is validated upfront
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdSync(), append(args, "--playlist-encoding", "xyz")...)), "unsupported encoding")
+}
+
+func TestCmdSyncSnapshotFailure(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(cmd.ValidateEnvironment).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "BuildWithProgress")).Return(nil).Build()
+	mockey.Mock(spotify.Authenticate).Return(&spotify.Client{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Username")).Return("alice", nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "PlaylistSnapshot")).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	snapshotsData.Set("123", "snapshot")
+	assert.Nil(t, snapshotsData.Save(snapshotsPath))
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdSync(), "--plain", "-p", "123")), "ko")
+}
+
+func TestCmdSyncSnapshotLoadFailure(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(cmd.ValidateEnvironment).Return(nil).Build()
+	mockey.Mock(playlist.LoadSnapshots).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdSync(), "--plain")), "ko")
+}
+
+func TestCmdSyncSnapshotSaveFailure(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(cmd.ValidateEnvironment).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&index.Index{}, "BuildWithProgress")).Return(nil).Build()
+	mockey.Mock(spotify.Authenticate).Return(&spotify.Client{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Username")).Return("alice", nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Library")).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&playlist.Snapshots{}, "Save")).Return(errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testExecute(cmdSync(), "--plain")), "ko")
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/adrg/xdg"
+	"github.com/streambinder/spotitube/sys"
+	"gopkg.in/yaml.v3"
+)
+
+const Basename = "config.yaml"
+
+type Config struct {
+	SpotifyID   string         `yaml:"spotify_id,omitempty"`
+	SpotifyKey  string         `yaml:"spotify_key,omitempty"`
+	GeniusToken string         `yaml:"genius_token,omitempty"`
+	Providers   map[string]int `yaml:"providers,omitempty"`
+	// authorization callback, for every command which can authenticate
+	RedirectHost string `yaml:"redirect_host,omitempty"`
+	RedirectPort int    `yaml:"redirect_port,omitempty"`
+	// each profile overlays the rest of the configuration
+	Profiles map[string]*Config `yaml:"profiles,omitempty"`
+	// every other key holds the flags values of the homonymous command
+	Commands map[string]map[string]interface{} `yaml:",inline"`
+}
+
+func Path() string {
+	return sys.Fallback(os.Getenv("SPOTITUBE_CONFIG"), filepath.Join(xdg.ConfigHome, "spotitube", Basename))
+}
+
+func ProfileName() string {
+	return os.Getenv("SPOTITUBE_PROFILE")
+}
+
+// configuring is optional: without any file, defaults apply
+func Load(path string) (*Config, error) {
+	config := &Config{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return config, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// flags values of the given command, as they would be passed on the command line:
+// lists yield a value for each of their items
+func (config *Config) Flags(command string) map[string][]string {
+	flags := make(map[string][]string)
+	for flag, value := range config.Commands[command] {
+		values, ok := value.([]interface{})
+		if !ok {
+			values = []interface{}{value}
+		}
+		for _, value := range values {
+			flags[flag] = append(flags[flag], fmt.Sprint(value))
+		}
+	}
+	return flags
+}
+
+// credentials, keyed by the environment variable they map to
+func (config *Config) Credentials() map[string]string {
+	credentials := make(map[string]string)
+	for env, value := range map[string]string{
+		"SPOTIFY_ID":   config.SpotifyID,
+		"SPOTIFY_KEY":  config.SpotifyKey,
+		"GENIUS_TOKEN": config.GeniusToken,
+	} {
+		if value != "" {
+			credentials[env] = value
+		}
+	}
+	return credentials
+}
+
+// the configuration of the given profile: its credentials, providers weights,
+// authorization callback and flags values win over the top-level ones, while profiles with no
+// configuration of their own simply yield the top-level one
+func (config *Config) Profile(name string) *Config {
+	profile, ok := config.Profiles[name]
+	if !ok || profile == nil {
+		return config
+	}
+
+	merged := &Config{
+		SpotifyID:   sys.Fallback(profile.SpotifyID, config.SpotifyID),
+		SpotifyKey:  sys.Fallback(profile.SpotifyKey, config.SpotifyKey),
+		GeniusToken: sys.Fallback(profile.GeniusToken, config.GeniusToken),
+		Providers:   make(map[string]int),
+		Commands:    make(map[string]map[string]interface{}),
+	}
+	merged.RedirectHost = sys.Fallback(profile.RedirectHost, config.RedirectHost)
+	if merged.RedirectPort = profile.RedirectPort; merged.RedirectPort == 0 {
+		merged.RedirectPort = config.RedirectPort
+	}
+	for _, source := range []*Config{config, profile} {
+		for provider, weight := range source.Providers {
+			merged.Providers[provider] = weight
+		}
+		for command, flags := range source.Commands {
+			if _, ok := merged.Commands[command]; !ok {
+				merged.Commands[command] = make(map[string]interface{})
+			}
+			for flag, value := range flags {
+				merged.Commands[command][flag] = value
+			}
+		}
+	}
+	return merged
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bytedance/mockey"
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig = `spotify_id: id
+spotify_key: key
+providers:
+  youtube: 50
+redirect_host: 192.168.1.10
+redirect_port: 8080
+sync:
+  output: /tmp
+  manual: true
+  playlist:
+    - a
+    - b
+profiles:
+  alice:
+    spotify_id: alice
+    redirect_port: 8081
+    providers:
+      qobuz: 0
+    sync:
+      output: /tmp/alice
+    lookup:
+      random-size: 10
+  bob:
+`
+
+func BenchmarkConfig(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		TestLoad(&testing.T{})
+	}
+}
+
+func TestPath(t *testing.T) {
+	t.Setenv("SPOTITUBE_CONFIG", "")
+	assert.Equal(t, Basename, filepath.Base(Path()))
+	t.Setenv("SPOTITUBE_CONFIG", "/path/to/config.yaml")
+	assert.Equal(t, "/path/to/config.yaml", Path())
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), Basename)
+	assert.Nil(t, os.WriteFile(path, []byte(testConfig), 0o600))
+
+	// testing
+	config, err := Load(path)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{"youtube": 50}, config.Providers)
+	assert.Equal(t, map[string]string{"SPOTIFY_ID": "id", "SPOTIFY_KEY": "key"}, config.Credentials())
+	assert.Equal(t, map[string][]string{
+		"output":   {"/tmp"},
+		"manual":   {"true"},
+		"playlist": {"a", "b"},
+	}, config.Flags("sync"))
+	assert.Empty(t, config.Flags("lookup"))
+}
+
+func TestLoadNotExist(t *testing.T) {
+	config, err := Load(filepath.Join(t.TempDir(), Basename))
+	assert.Nil(t, err)
+	assert.Empty(t, config.Credentials())
+	assert.Empty(t, config.Flags("sync"))
+}
+
+func TestLoadFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(os.ReadFile).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	_, err := Load(Basename)
+	assert.EqualError(t, err, "ko")
+}
+
+func TestLoadMalformed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), Basename)
+	assert.Nil(t, os.WriteFile(path, []byte("sync: output"), 0o600))
+
+	// testing
+	_, err := Load(path)
+	assert.NotNil(t, err)
+}
+
+func TestProfileName(t *testing.T) {
+	t.Setenv("SPOTITUBE_PROFILE", "alice")
+	assert.Equal(t, "alice", ProfileName())
+}
+
+func TestProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), Basename)
+	assert.Nil(t, os.WriteFile(path, []byte(testConfig), 0o600))
+
+	// testing
+	config, err := Load(path)
+	assert.Nil(t, err)
+	assert.Equal(t, config, config.Profile(""))
+	assert.Equal(t, config, config.Profile("bob"))
+	assert.Equal(t, config, config.Profile("carol"))
+	profile := config.Profile("alice")
+	assert.Equal(t, map[string]int{"youtube": 50, "qobuz": 0}, profile.Providers)
+	assert.Equal(t, map[string]string{"SPOTIFY_ID": "alice", "SPOTIFY_KEY": "key"}, profile.Credentials())
+	assert.Equal(t, "192.168.1.10", profile.RedirectHost)
+	assert.Equal(t, 8081, profile.RedirectPort)
+	assert.Equal(t, 8080, (&Config{RedirectPort: 8080, Profiles: map[string]*Config{"carol": {}}}).Profile("carol").RedirectPort)
+	assert.Equal(t, map[string][]string{
+		"output":   {"/tmp/alice"},
+		"manual":   {"true"},
+		"playlist": {"a", "b"},
+	}, profile.Flags("sync"))
+	assert.Equal(t, map[string][]string{"random-size": {"10"}}, profile.Flags("lookup"))
+}
@@ -0,0 +1,159 @@
+package ffmeta
+
+import (
+	"os"
+
+	"github.com/streambinder/spotitube/lyrics"
+	"github.com/streambinder/spotitube/sys"
+	"github.com/streambinder/spotitube/sys/cmd"
+)
+
+// well-known keys get mapped by ffmpeg onto each container's
+// own fields (e.g. TRACKNUMBER for Vorbis comments, trkn for MP4)
+const (
+	keyTitle       = "title"
+	keyArtist      = "artist"
+	keyAlbum       = "album"
+	keyYear        = "date"
+	keyTrackNumber = "track"
+	keyLyrics      = "lyrics"
+	keySpotifyID   = "spotify_id"
+	keyArtworkURL  = "artwork_url"
+	keyDuration    = "duration"
+	keyUpstreamURL = "upstream_url"
+)
+
+// tags of containers with no native Go tagger (i.e. M4A),
+// read and written through ffmpeg
+type Tag struct {
+	path    string
+	tags    map[string]string
+	picture []byte
+}
+
+func New(path string) *Tag {
+	return &Tag{path, make(map[string]string), nil}
+}
+
+func Open(path string) (*Tag, error) {
+	tags, err := cmd.FFmpeg().Tags(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Tag{path, tags, nil}, nil
+}
+
+func (tag *Tag) SetTitle(title string) {
+	tag.tags[keyTitle] = title
+}
+
+func (tag *Tag) Title() string {
+	return tag.tags[keyTitle]
+}
+
+func (tag *Tag) SetArtist(artist string) {
+	tag.tags[keyArtist] = artist
+}
+
+func (tag *Tag) Artist() string {
+	return tag.tags[keyArtist]
+}
+
+func (tag *Tag) SetAlbum(album string) {
+	tag.tags[keyAlbum] = album
+}
+
+func (tag *Tag) Album() string {
+	return tag.tags[keyAlbum]
+}
+
+func (tag *Tag) SetYear(year string) {
+	tag.tags[keyYear] = year
+}
+
+func (tag *Tag) Year() string {
+	return tag.tags[keyYear]
+}
+
+func (tag *Tag) SetTrackNumber(number string) {
+	tag.tags[keyTrackNumber] = number
+}
+
+func (tag *Tag) TrackNumber() string {
+	return tag.tags[keyTrackNumber]
+}
+
+func (tag *Tag) SetSpotifyID(id string) {
+	tag.tags[keySpotifyID] = id
+}
+
+func (tag *Tag) SpotifyID() string {
+	return tag.tags[keySpotifyID]
+}
+
+func (tag *Tag) SetArtworkURL(url string) {
+	tag.tags[keyArtworkURL] = url
+}
+
+func (tag *Tag) ArtworkURL() string {
+	return tag.tags[keyArtworkURL]
+}
+
+func (tag *Tag) SetDuration(duration string) {
+	tag.tags[keyDuration] = duration
+}
+
+func (tag *Tag) Duration() string {
+	return tag.tags[keyDuration]
+}
+
+func (tag *Tag) SetUpstreamURL(url string) {
+	tag.tags[keyUpstreamURL] = url
+}
+
+func (tag *Tag) UpstreamURL() string {
+	return tag.tags[keyUpstreamURL]
+}
+
+// synced lyrics are kept in LRC format, having no dedicated atom
+func (tag *Tag) SetLyrics(_, data string) {
+	tag.tags[keyLyrics] = data
+}
+
+func (tag *Tag) UnsynchronizedLyrics() string {
+	return tag.tags[keyLyrics]
+}
+
+func (tag *Tag) SynchronizedLyrics() []lyrics.SyncedLine {
+	return lyrics.GetSync(tag.UnsynchronizedLyrics())
+}
+
+func (tag *Tag) SetAttachedPicture(picture []byte) {
+	tag.picture = picture
+}
+
+// pictures are not read back, only the ones set get returned
+func (tag *Tag) AttachedPicture() (string, []byte) {
+	if len(tag.picture) > 0 {
+		return "image/jpeg", tag.picture
+	}
+	return "", []byte{}
+}
+
+func (tag *Tag) Save() error {
+	if len(tag.picture) == 0 {
+		return cmd.FFmpeg().Tag(tag.path, tag.tags, "")
+	}
+
+	picture := sys.FileBaseStem(tag.path) + ".cover.jpg"
+	if err := os.WriteFile(picture, tag.picture, 0o644); err != nil {
+		return err
+	}
+	defer os.Remove(picture)
+	return cmd.FFmpeg().Tag(tag.path, tag.tags, picture)
+}
+
+// the ffmpeg run behind Open holds nothing open
+func (tag *Tag) Close() error {
+	return nil
+}
@@ -0,0 +1,124 @@
+package ffmeta
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bytedance/mockey"
+	"github.com/streambinder/spotitube/lyrics"
+	"github.com/streambinder/spotitube/sys"
+	"github.com/streambinder/spotitube/sys/cmd"
+	"github.com/stretchr/testify/assert"
+)
+
+func BenchmarkFFmeta(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		TestNew(&testing.T{})
+	}
+}
+
+func TestNew(t *testing.T) {
+	// testing
+	tag := New("track.flac")
+	assert.Empty(t, tag.SpotifyID())
+	mimeType, picture := tag.AttachedPicture()
+	assert.Empty(t, mimeType)
+	assert.Empty(t, picture)
+
+	tag.SetTitle("Title")
+	tag.SetArtist("Artist")
+	tag.SetAlbum("Album")
+	tag.SetYear("1970")
+	tag.SetTrackNumber("1")
+	tag.SetSpotifyID("Spotify ID")
+	tag.SetArtworkURL("Artwork URL")
+	tag.SetDuration("60")
+	tag.SetUpstreamURL("Upstream URL")
+	tag.SetLyrics("Title", "[00:27.37]lyrics")
+	tag.SetAttachedPicture([]byte("picture"))
+
+	assert.Equal(t, "Title", tag.Title())
+	assert.Equal(t, "Artist", tag.Artist())
+	assert.Equal(t, "Album", tag.Album())
+	assert.Equal(t, "1970", tag.Year())
+	assert.Equal(t, "1", tag.TrackNumber())
+	assert.Equal(t, "Spotify ID", tag.SpotifyID())
+	assert.Equal(t, "Artwork URL", tag.ArtworkURL())
+	assert.Equal(t, "60", tag.Duration())
+	assert.Equal(t, "Upstream URL", tag.UpstreamURL())
+	assert.Equal(t, "[00:27.37]lyrics", tag.UnsynchronizedLyrics())
+	assert.Equal(t, []lyrics.SyncedLine{{Time: 27370, Text: "lyrics"}}, tag.SynchronizedLyrics())
+	mimeType, picture = tag.AttachedPicture()
+	assert.Equal(t, "image/jpeg", mimeType)
+	assert.Equal(t, []byte("picture"), picture)
+	assert.Nil(t, tag.Close())
+}
+
+func TestOpen(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(cmd.FFmpegCmd{}, "Tags")).Return(map[string]string{
+		keySpotifyID:   "Spotify ID",
+		keyUpstreamURL: "Upstream URL",
+	}, nil).Build()
+
+	// testing
+	tag, err := Open("track.opus")
+	assert.Nil(t, err)
+	assert.Equal(t, "Spotify ID", tag.SpotifyID())
+	assert.Equal(t, "Upstream URL", tag.UpstreamURL())
+}
+
+func TestOpenFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(cmd.FFmpegCmd{}, "Tags")).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(Open("track.opus")), "ko")
+}
+
+func TestSave(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	var picture []byte
+	mockey.Mock(mockey.GetMethod(cmd.FFmpegCmd{}, "Tag")).To(func(path string, tags map[string]string, cover string) error {
+		assert.Equal(t, "Title", tags[keyTitle])
+		picture = sys.ErrWrap([]byte{})(os.ReadFile(cover))
+		return nil
+	}).Build()
+
+	// testing
+	path := filepath.Join(t.TempDir(), "track.flac")
+	tag := New(path)
+	tag.SetTitle("Title")
+	tag.SetAttachedPicture([]byte("picture"))
+	assert.Nil(t, tag.Save())
+	assert.Equal(t, []byte("picture"), picture)
+	assert.NoFileExists(t, filepath.Join(filepath.Dir(path), "track.cover.jpg"))
+}
+
+func TestSaveNoPicture(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(cmd.FFmpegCmd{}, "Tag")).To(func(_ string, _ map[string]string, cover string) error {
+		assert.Empty(t, cover)
+		return nil
+	}).Build()
+
+	// testing
+	assert.Nil(t, New("track.m4a").Save())
+}
+
+func TestSavePictureFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(os.WriteFile).Return(errors.New("ko")).Build()
+
+	// testing
+	tag := New("track.m4a")
+	tag.SetAttachedPicture([]byte("picture"))
+	assert.EqualError(t, tag.Save(), "ko")
+}
@@ -11,6 +11,7 @@ const (
 	frameAttachedPicture      = "Attached picture"
 	frameTrackNumber          = "Track number/Position in set"
 	frameUnsynchronizedLyrics = "Unsynchronised lyrics/text transcription"
+	frameSynchronizedLyrics   = "SYLT" // not among the library common IDs
 	frameUserDefinedText      = "User defined text information frame"
 	frameSpotifyID            = "Spotify ID"
 	frameArtworkURL           = "Artwork URL"
@@ -18,11 +19,19 @@ const (
 	frameUpstreamURL          = "Upstream URL"
 )
 
+// players mostly expect synced lyrics as LRC within the USLT frame,
+// hence SYLT frames only get written when explicitly asked to
+var synchronizedLyrics = false
+
 type Tag struct {
 	id3v2.Tag
 	Cache map[string]string
 }
 
+func SetSynchronizedLyrics(enabled bool) {
+	synchronizedLyrics = enabled
+}
+
 func Open(path string, options id3v2.Options) (*Tag, error) {
 	tag, err := id3v2.Open(path, options)
 	if err != nil {
@@ -131,8 +140,13 @@ func (tag *Tag) AttachedPicture() (string, []byte) {
 	return "", []byte{}
 }
 
-func (tag *Tag) SetLyrics(title, lyrics string) {
-	tag.setUnsynchronizedLyrics(title, lyrics)
+func (tag *Tag) SetLyrics(title, data string) {
+	if !synchronizedLyrics || !lyrics.IsSynced(data) {
+		tag.setUnsynchronizedLyrics(title, data)
+		return
+	}
+	tag.setUnsynchronizedLyrics(title, lyrics.GetPlain(data))
+	tag.setSynchronizedLyrics(title, lyrics.GetSync(data))
 }
 
 func (tag *Tag) setUnsynchronizedLyrics(title, data string) {
@@ -140,7 +154,7 @@ func (tag *Tag) setUnsynchronizedLyrics(title, data string) {
 		Encoding:          tag.DefaultEncoding(),
 		Language:          "eng",
 		ContentDescriptor: title,
-		Lyrics:            lyrics.GetPlain(data),
+		Lyrics:            data,
 	})
 }
 
@@ -152,6 +166,31 @@ func (tag *Tag) UnsynchronizedLyrics() string {
 	return ""
 }
 
+// frames parsed from the file come as unknown ones, which never share
+// an identifier with the new one: they must be dropped explicitly
+func (tag *Tag) setSynchronizedLyrics(title string, lines []lyrics.SyncedLine) {
+	tag.DeleteFrames(frameSynchronizedLyrics)
+	tag.AddFrame(frameSynchronizedLyrics, synchronizedLyricsFrame{
+		language:   "eng",
+		descriptor: title,
+		lines:      lines,
+	})
+}
+
+// parsed SYLT frames come as unknown ones, while
+// LRC within the USLT frame is looked up otherwise
+func (tag *Tag) SynchronizedLyrics() []lyrics.SyncedLine {
+	switch frame := tag.GetLastFrame(frameSynchronizedLyrics).(type) {
+	case synchronizedLyricsFrame:
+		return frame.lines
+	case id3v2.UnknownFrame:
+		if lines, err := decodeSynchronizedLyrics(frame.Body); err == nil {
+			return lines
+		}
+	}
+	return lyrics.GetSync(tag.UnsynchronizedLyrics())
+}
+
 func (tag *Tag) Close() error {
 	if err := tag.Tag.Close(); err != id3v2.ErrNoFile && err != nil {
 		return err
@@ -0,0 +1,134 @@
+package id3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"unicode/utf16"
+
+	"github.com/streambinder/spotitube/lyrics"
+)
+
+const (
+	encodingISO     = 0
+	encodingUTF16   = 1
+	encodingUTF16BE = 2
+	encodingUTF8    = 3
+
+	timestampMillis   = 2
+	contentTypeLyrics = 1
+)
+
+var errSynchronizedLyrics = errors.New("corrupted synchronised lyrics frame")
+
+// the library does not know how to serialize SYLT frames:
+// text gets written as UTF-16 with BOM, which both
+// ID3v2.3 and ID3v2.4 support, with millisecond timestamps
+type synchronizedLyricsFrame struct {
+	language   string
+	descriptor string
+	lines      []lyrics.SyncedLine
+}
+
+func (frame synchronizedLyricsFrame) UniqueIdentifier() string {
+	return frame.language + frame.descriptor
+}
+
+func (frame synchronizedLyricsFrame) Size() int {
+	return len(frame.encode())
+}
+
+func (frame synchronizedLyricsFrame) WriteTo(writer io.Writer) (int64, error) {
+	written, err := writer.Write(frame.encode())
+	return int64(written), err
+}
+
+func (frame synchronizedLyricsFrame) encode() []byte {
+	var buffer bytes.Buffer
+	buffer.WriteByte(encodingUTF16)
+	buffer.WriteString(frame.language)
+	buffer.Write([]byte{timestampMillis, contentTypeLyrics})
+	writeText(&buffer, frame.descriptor)
+	for _, line := range frame.lines {
+		writeText(&buffer, line.Text)
+		buffer.Write(binary.BigEndian.AppendUint32(nil, line.Time))
+	}
+	return buffer.Bytes()
+}
+
+// frames written by other taggers can use any encoding,
+// while only millisecond timestamps are supported
+func decodeSynchronizedLyrics(body []byte) ([]lyrics.SyncedLine, error) {
+	if len(body) < 6 || body[0] > encodingUTF8 || body[4] != timestampMillis {
+		return nil, errSynchronizedLyrics
+	}
+
+	encoding, data := body[0], body[6:]
+	_, data, err := readText(data, encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := []lyrics.SyncedLine{}
+	for len(data) > 0 {
+		var text string
+		if text, data, err = readText(data, encoding); err != nil || len(data) < 4 {
+			return nil, errSynchronizedLyrics
+		}
+		lines = append(lines, lyrics.SyncedLine{Time: binary.BigEndian.Uint32(data), Text: text})
+		data = data[4:]
+	}
+	return lines, nil
+}
+
+func writeText(buffer *bytes.Buffer, text string) {
+	buffer.Write([]byte{0xff, 0xfe})
+	for _, unit := range utf16.Encode([]rune(text)) {
+		buffer.Write(binary.LittleEndian.AppendUint16(nil, unit))
+	}
+	buffer.Write([]byte{0, 0})
+}
+
+// reads a terminated string, returning what follows it
+func readText(data []byte, encoding byte) (string, []byte, error) {
+	if encoding == encodingISO || encoding == encodingUTF8 {
+		end := bytes.IndexByte(data, 0)
+		if end < 0 {
+			return "", nil, errSynchronizedLyrics
+		}
+		text := data[:end]
+		if encoding == encodingISO {
+			// ISO-8859-1 bytes map straight onto the first Unicode code points
+			runes := make([]rune, len(text))
+			for index, value := range text {
+				runes[index] = rune(value)
+			}
+			return string(runes), data[end+1:], nil
+		}
+		return string(text), data[end+1:], nil
+	}
+
+	end := 0
+	for end+1 < len(data) && (data[end] != 0 || data[end+1] != 0) {
+		end += 2
+	}
+	if end+1 >= len(data) {
+		return "", nil, errSynchronizedLyrics
+	}
+
+	text, order := data[:end], binary.ByteOrder(binary.BigEndian)
+	if encoding == encodingUTF16 && len(text) >= 2 {
+		switch {
+		case text[0] == 0xff && text[1] == 0xfe:
+			text, order = text[2:], binary.LittleEndian
+		case text[0] == 0xfe && text[1] == 0xff:
+			text = text[2:]
+		}
+	}
+	units := make([]uint16, len(text)/2)
+	for index := range units {
+		units[index] = order.Uint16(text[index*2:])
+	}
+	return string(utf16.Decode(units)), data[end+2:], nil
+}
@@ -0,0 +1,158 @@
+package id3
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bogem/id3v2/v2"
+	"github.com/streambinder/spotitube/lyrics"
+	"github.com/streambinder/spotitube/sys"
+	"github.com/stretchr/testify/assert"
+)
+
+var testSyncedLines = []lyrics.SyncedLine{{Time: 27370, Text: "lyrics"}, {Time: 61150, Text: "più lyrics"}}
+
+func BenchmarkSYLT(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		TestSynchronizedLyrics(&testing.T{})
+	}
+}
+
+// header, descriptor and lines, with the given text encoder
+func testSYLT(encoding byte, text func(string) []byte) []byte {
+	body := append([]byte{encoding, 'e', 'n', 'g', timestampMillis, contentTypeLyrics}, text("title")...)
+	for _, line := range testSyncedLines {
+		body = append(append(body, text(line.Text)...), 0, 0, byte(line.Time>>8), byte(line.Time))
+	}
+	return body
+}
+
+func TestSynchronizedLyrics(t *testing.T) {
+	SetSynchronizedLyrics(true)
+	defer SetSynchronizedLyrics(false)
+
+	path := filepath.Join(t.TempDir(), "track.mp3")
+	assert.Nil(t, os.WriteFile(path, []byte("mpeg audio frames"), 0o600))
+
+	tag, err := Open(path, id3v2.Options{Parse: true})
+	assert.Nil(t, err)
+	tag.SetLyrics("title", "[00:27.37]lyrics\n[01:01.15]più lyrics")
+	assert.Equal(t, "lyrics\npiù lyrics", tag.UnsynchronizedLyrics())
+	assert.Equal(t, testSyncedLines, tag.SynchronizedLyrics())
+	assert.Nil(t, tag.Save())
+	assert.Nil(t, tag.Close())
+
+	// read back from the file, as an unknown frame
+	tag, err = Open(path, id3v2.Options{Parse: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "lyrics\npiù lyrics", tag.UnsynchronizedLyrics())
+	assert.Equal(t, testSyncedLines, tag.SynchronizedLyrics())
+	assert.Nil(t, tag.Close())
+}
+
+func TestSynchronizedLyricsRetag(t *testing.T) {
+	SetSynchronizedLyrics(true)
+	defer SetSynchronizedLyrics(false)
+
+	path := filepath.Join(t.TempDir(), "track.mp3")
+	assert.Nil(t, os.WriteFile(path, []byte("mpeg audio frames"), 0o600))
+	for _, data := range []string{"[00:01.00]old lyrics", "[00:27.37]lyrics\n[01:01.15]più lyrics"} {
+		tag, err := Open(path, id3v2.Options{Parse: true})
+		assert.Nil(t, err)
+		tag.SetLyrics("title", data)
+		assert.Nil(t, tag.Save())
+		assert.Nil(t, tag.Close())
+	}
+
+	// testing
+	tag, err := Open(path, id3v2.Options{Parse: true})
+	assert.Nil(t, err)
+	assert.Len(t, tag.GetFrames(frameSynchronizedLyrics), 1)
+	assert.Equal(t, testSyncedLines, tag.SynchronizedLyrics())
+	assert.Nil(t, tag.Close())
+}
+
+func TestSynchronizedLyricsDisabled(t *testing.T) {
+	tag := &Tag{*id3v2.NewEmptyTag(), map[string]string{}}
+	tag.SetLyrics("title", "[00:27.37]lyrics")
+	assert.Equal(t, "[00:27.37]lyrics", tag.UnsynchronizedLyrics())
+	assert.Nil(t, tag.GetLastFrame(frameSynchronizedLyrics))
+	assert.Equal(t, []lyrics.SyncedLine{{Time: 27370, Text: "lyrics"}}, tag.SynchronizedLyrics())
+}
+
+func TestSynchronizedLyricsPlain(t *testing.T) {
+	SetSynchronizedLyrics(true)
+	defer SetSynchronizedLyrics(false)
+
+	tag := &Tag{*id3v2.NewEmptyTag(), map[string]string{}}
+	tag.SetLyrics("title", "lyrics")
+	assert.Equal(t, "lyrics", tag.UnsynchronizedLyrics())
+	assert.Nil(t, tag.GetLastFrame(frameSynchronizedLyrics))
+	assert.Equal(t, []lyrics.SyncedLine{}, tag.SynchronizedLyrics())
+}
+
+func TestSynchronizedLyricsCorrupted(t *testing.T) {
+	tag := &Tag{*id3v2.NewEmptyTag(), map[string]string{}}
+	tag.AddFrame(frameSynchronizedLyrics, id3v2.UnknownFrame{Body: []byte{encodingUTF8}})
+	assert.Equal(t, []lyrics.SyncedLine{}, tag.SynchronizedLyrics())
+}
+
+func TestSynchronizedLyricsFrame(t *testing.T) {
+	assert.Equal(t, "engtitle", synchronizedLyricsFrame{"eng", "title", nil}.UniqueIdentifier())
+}
+
+func TestSynchronizedLyricsFrameWriteFailure(t *testing.T) {
+	assert.EqualError(t, sys.ErrOnly(synchronizedLyricsFrame{}.WriteTo(testFailingWriter{})), "ko")
+}
+
+type testFailingWriter struct{}
+
+func (testFailingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("ko")
+}
+
+func TestDecodeSynchronizedLyrics(t *testing.T) {
+	for name, body := range map[string][]byte{
+		"iso": testSYLT(encodingISO, func(text string) []byte {
+			var data []byte
+			for _, value := range text {
+				data = append(data, byte(value))
+			}
+			return append(data, 0)
+		}),
+		"utf8": testSYLT(encodingUTF8, func(text string) []byte {
+			return append([]byte(text), 0)
+		}),
+		"utf16": synchronizedLyricsFrame{"eng", "title", testSyncedLines}.encode(),
+		"utf16be": testSYLT(encodingUTF16, func(text string) []byte {
+			var buffer bytes.Buffer
+			writeText(&buffer, text)
+			data := append([]byte{0xfe, 0xff}, buffer.Bytes()[2:]...)
+			for index := 2; index < len(data)-2; index += 2 {
+				data[index], data[index+1] = data[index+1], data[index]
+			}
+			return data
+		}),
+	} {
+		lines, err := decodeSynchronizedLyrics(body)
+		assert.Nil(t, err, name)
+		assert.Equal(t, testSyncedLines, lines, name)
+	}
+}
+
+func TestDecodeSynchronizedLyricsFailure(t *testing.T) {
+	for name, body := range map[string][]byte{
+		"short":      {encodingUTF8},
+		"encoding":   {4, 'e', 'n', 'g', timestampMillis, contentTypeLyrics, 0},
+		"timestamp":  {encodingUTF8, 'e', 'n', 'g', 1, contentTypeLyrics, 0},
+		"descriptor": {encodingUTF8, 'e', 'n', 'g', timestampMillis, contentTypeLyrics, 't'},
+		"wide":       {encodingUTF16BE, 'e', 'n', 'g', timestampMillis, contentTypeLyrics, 0, 't', 0},
+		"line":       {encodingUTF8, 'e', 'n', 'g', timestampMillis, contentTypeLyrics, 0, 'l'},
+		"time":       {encodingUTF8, 'e', 'n', 'g', timestampMillis, contentTypeLyrics, 0, 'l', 0, 0},
+	} {
+		assert.EqualError(t, sys.ErrOnly(decodeSynchronizedLyrics(body)), errSynchronizedLyrics.Error(), name)
+	}
+}
@@ -3,12 +3,14 @@ package index
 import (
 	"io/fs"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"sync"
 
 	"github.com/gosimple/slug"
 	"github.com/streambinder/spotitube/entity"
-	"github.com/streambinder/spotitube/entity/id3"
+	"github.com/streambinder/spotitube/entity/tags"
+	"github.com/streambinder/spotitube/sys"
 )
 
 const (
@@ -18,10 +20,36 @@ const (
 	Installed        // synced and successfully installed
 )
 
+var statusNames = map[int]string{
+	Offline:   "offline",
+	Online:    "online",
+	Flush:     "flush",
+	Installed: "installed",
+}
+
+// tracks to be parsed at once while building
+var parseWorkers = runtime.NumCPU()
+
+// a track file met while walking, with its
+// stored or freshly parsed entry, if any
+type walkedTrack struct {
+	path    string
+	absPath string
+	info    fs.FileInfo
+	entry   *storeEntry
+	status  int
+}
+
 type Index struct {
-	ids   map[string]int // track Spotify IDs for canonical matches across renames
-	paths map[string]int // final paths catch same-song collisions across upstream IDs
-	lock  sync.RWMutex
+	ids      map[string]int         // track Spotify IDs for canonical matches across renames
+	paths    map[string]int         // final paths catch same-song collisions across upstream IDs
+	files    map[string]string      // indexed files by Spotify ID, for them to be pruned
+	owners   map[string]string      // Spotify IDs by final path, to detect collisions
+	sidecars map[string]string      // lyrics sidecars by path, to the track they sit beside
+	store    map[string]*storeEntry // parsed files by absolute path, persisted across builds
+	excluded map[string]bool        // absolute paths of folders not to walk through
+	skipped  map[string]error       // unparsable files of the last walk, with the reason
+	lock     sync.RWMutex
 }
 
 func keyFromTrackID(track *entity.Track) string {
@@ -36,67 +64,219 @@ func keyFromID(id string) string {
 	return id
 }
 
+// paths are relative to the indexed root, so that same-named tracks in
+// different folders do not collide: each segment is slugged on its own,
+// for Artist/Title.mp3 not to match Artist - Title.mp3
 func keyFromPath(path string) string {
-	return slug.Make(filepath.Base(path))
+	segments := strings.Split(filepath.ToSlash(filepath.Clean(path)), "/")
+	for i, segment := range segments {
+		segments[i] = slug.Make(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+func StatusName(status int) string {
+	return statusNames[status]
 }
 
 func New() *Index {
 	return &Index{
-		ids:   make(map[string]int),
-		paths: make(map[string]int),
-		lock:  sync.RWMutex{},
+		ids:      make(map[string]int),
+		paths:    make(map[string]int),
+		files:    make(map[string]string),
+		owners:   make(map[string]string),
+		sidecars: make(map[string]string),
+		store:    make(map[string]*storeEntry),
+		excluded: make(map[string]bool),
+		skipped:  make(map[string]error),
+		lock:     sync.RWMutex{},
 	}
 }
 
+// Exclude leaves the given folder out of any later build
+func (index *Index) Exclude(path string) {
+	index.lock.Lock()
+	defer index.lock.Unlock()
+	index.excluded[sys.ErrWrap(path)(filepath.Abs(path))] = true
+}
+
+func (index *Index) isExcluded(path string) bool {
+	index.lock.RLock()
+	defer index.lock.RUnlock()
+	return index.excluded[sys.ErrWrap(path)(filepath.Abs(path))]
+}
+
 func (index *Index) Build(path string, init ...int) error {
 	return index.BuildWithProgress(path, nil, init...)
 }
 
-func (index *Index) BuildWithProgress(path string, indexed chan<- string, init ...int) error {
+func (index *Index) BuildWithProgress(root string, indexed chan<- string, init ...int) error {
 	status := Offline
 	for _, override := range init {
 		status = override
 	}
 
-	return filepath.WalkDir(path, func(path string, entry fs.DirEntry, err error) error {
-		// stop on root (or any subsequent inner directory, which is not relevant for us)
-		// directory walk failure
+	var (
+		seen     = make(map[string]bool)
+		stems    = make(map[string]string) // track files by path stem, tagged or not
+		sidecars []string
+		skipped  = make(map[string]error)
+		tracks   []*walkedTrack
+		queue    = make(chan *walkedTrack)
+		lock     sync.Mutex // guards skipped against parsing workers
+		wg       sync.WaitGroup
+	)
+
+	// tracks which changed since the store got saved are parsed by a pool
+	// of workers, while walking, as parsing some formats spawns processes
+	for range max(parseWorkers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for track := range queue {
+				entry, err := parse(track.path, track.info)
+				if err != nil {
+					lock.Lock()
+					skipped[track.path] = err
+					lock.Unlock()
+					continue
+				}
+				index.storeSet(track.absPath, entry)
+				track.entry = entry
+				if indexed != nil && len(entry.ID) > 0 {
+					indexed <- track.path
+				}
+			}
+		}()
+	}
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		// stop on root (or any subsequent inner directory) walk failure
 		if err != nil {
 			return err
 		}
 
-		// skip any inner directory from walk
-		if entry.IsDir() && entry.Name() != filepath.Base(path) {
-			return fs.SkipDir
+		// walk through inner directories, as tracks may be laid out
+		// in nested folders, except for hidden or excluded ones (e.g. trash bins)
+		if entry.IsDir() {
+			if path != root && (strings.HasPrefix(entry.Name(), ".") || index.isExcluded(path)) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		// lyrics sidecars get bound to their tracks once done walking
+		if entity.IsSidecar(path) {
+			sidecars = append(sidecars, path)
+			return nil
 		}
 
 		// skip any file other than supported tracks
-		if !strings.HasSuffix(filepath.Ext(path), entity.TrackFormat) {
+		if !entity.IsTrack(path) {
 			return nil
 		}
+		stems[sys.FileBaseStem(path)] = path
 
-		tag, err := id3.OpenSpotifyID(path)
+		// a single broken file does not prevent the rest
+		// of the library from being indexed, it is skipped
+		info, err := entry.Info()
 		if err != nil {
-			return err
+			lock.Lock()
+			skipped[path] = err
+			lock.Unlock()
+			return nil
 		}
 
-		if id := tag.SpotifyID(); len(id) > 0 {
-			index.SetID(id, status)
-			index.SetPath(path, status)
-			if indexed != nil {
+		// tracks which did not change since the store got saved
+		// are not parsed again, the stored entry and status are used
+		track := &walkedTrack{path, sys.ErrWrap(path)(filepath.Abs(path)), info, nil, status}
+		seen[track.absPath] = true
+		tracks = append(tracks, track)
+		if stored, ok := index.storeGet(track.absPath, info); ok {
+			track.entry, track.status = stored, stored.status(status)
+			if indexed != nil && len(stored.ID) > 0 {
 				indexed <- path
 			}
+			return nil
 		}
-
-		return tag.Close()
+		queue <- track
+		return nil
 	})
+	close(queue)
+	wg.Wait()
+	if err != nil {
+		return err
+	}
+
+	// tracks are bound in walking order, whichever got parsed first
+	for _, track := range tracks {
+		if track.entry == nil || len(track.entry.ID) == 0 {
+			continue
+		}
+		index.SetID(track.entry.ID, track.status)
+		index.SetPath(track.path, track.status)
+		index.setFile(track.entry.ID, track.path)
+	}
+	index.storePrune(sys.ErrWrap(root)(filepath.Abs(root)), seen)
+	index.setSidecars(sidecars, stems)
+	index.lock.Lock()
+	defer index.lock.Unlock()
+	index.skipped = skipped
+	return nil
+}
+
+// sidecars only reflect the last walk, as they are not parsed and
+// cannot be matched by ID: orphaned ones point to no track at all
+func (index *Index) setSidecars(sidecars []string, stems map[string]string) {
+	index.lock.Lock()
+	defer index.lock.Unlock()
+	index.sidecars = make(map[string]string, len(sidecars))
+	for _, path := range sidecars {
+		index.sidecars[path] = stems[sys.FileBaseStem(path)]
+	}
+}
+
+// a mixed-format library deduplicates on Spotify IDs,
+// whichever the container they got serialized into
+func parse(path string, info fs.FileInfo) (*storeEntry, error) {
+	tag, err := tags.Open(path, tags.ParseSpotifyID)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &storeEntry{
+		ModTime:     info.ModTime(),
+		Size:        info.Size(),
+		ID:          tag.SpotifyID(),
+		UpstreamURL: tag.UpstreamURL(),
+	}
+	return entry, tag.Close()
 }
 
 func (index *Index) Set(track *entity.Track, value int) {
 	index.lock.Lock()
 	defer index.lock.Unlock()
+	index.set(track, value)
+}
+
+// sets the given status only if the track is not indexed yet, atomically,
+// so that concurrent workers never get to claim the same track twice
+func (index *Index) SetIfAbsent(track *entity.Track, value int) (int, bool) {
+	index.lock.Lock()
+	defer index.lock.Unlock()
+	if current, ok := index.get(track); ok {
+		return current, true
+	}
+	index.set(track, value)
+	return value, false
+}
+
+func (index *Index) set(track *entity.Track, value int) {
 	if len(track.ID) > 0 {
 		index.ids[keyFromTrackID(track)] = value
+		if _, ok := index.owners[keyFromTrackPath(track)]; !ok {
+			index.owners[keyFromTrackPath(track)] = track.ID
+		}
 	}
 	index.paths[keyFromTrackPath(track)] = value
 }
@@ -113,9 +293,66 @@ func (index *Index) SetPath(path string, value int) {
 	index.paths[keyFromPath(path)] = value
 }
 
+func (index *Index) setFile(id, path string) {
+	index.lock.Lock()
+	defer index.lock.Unlock()
+	index.files[keyFromID(id)] = path
+	index.owners[keyFromPath(path)] = id
+}
+
+// a track collides with another one whenever its final
+// path is already owned by a different Spotify ID
+func (index *Index) Collision(track *entity.Track) (string, bool) {
+	index.lock.RLock()
+	defer index.lock.RUnlock()
+	owner, ok := index.owners[keyFromTrackPath(track)]
+	return owner, ok && owner != track.ID
+}
+
+// returns a copy of the indexed file paths, keyed by Spotify ID
+func (index *Index) Files() map[string]string {
+	index.lock.RLock()
+	defer index.lock.RUnlock()
+
+	files := make(map[string]string, len(index.files))
+	for id, path := range index.files {
+		files[id] = path
+	}
+	return files
+}
+
+// returns a copy of the files the last build could not parse
+func (index *Index) Skipped() map[string]error {
+	index.lock.RLock()
+	defer index.lock.RUnlock()
+
+	skipped := make(map[string]error, len(index.skipped))
+	for path, err := range index.skipped {
+		skipped[path] = err
+	}
+	return skipped
+}
+
+// returns a copy of the indexed lyrics sidecars, each pointing
+// to the track it sits beside, if any
+func (index *Index) Sidecars() map[string]string {
+	index.lock.RLock()
+	defer index.lock.RUnlock()
+
+	sidecars := make(map[string]string, len(index.sidecars))
+	for path, track := range index.sidecars {
+		sidecars[path] = track
+	}
+	return sidecars
+}
+
 func (index *Index) Get(track *entity.Track) (int, bool) {
 	index.lock.RLock()
 	defer index.lock.RUnlock()
+	return index.get(track)
+}
+
+func (index *Index) get(track *entity.Track) (int, bool) {
 	if len(track.ID) > 0 {
 		value, ok := index.ids[keyFromTrackID(track)]
 		if ok {
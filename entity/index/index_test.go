@@ -3,14 +3,18 @@ package index
 import (
 	"errors"
 	"io/fs"
+	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/bogem/id3v2/v2"
 	"github.com/bytedance/mockey"
 	"github.com/streambinder/spotitube/entity"
 	"github.com/streambinder/spotitube/entity/id3"
+	"github.com/streambinder/spotitube/entity/vorbis"
 	"github.com/streambinder/spotitube/sys"
+	"github.com/streambinder/spotitube/sys/cmd"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -34,7 +38,19 @@ func (e DirEntry) Type() fs.FileMode {
 }
 
 func (e DirEntry) Info() (fs.FileInfo, error) {
-	return nil, nil
+	return FileInfo{}, nil
+}
+
+type FileInfo struct {
+	fs.FileInfo
+}
+
+func (i FileInfo) ModTime() time.Time {
+	return time.Time{}
+}
+
+func (i FileInfo) Size() int64 {
+	return 0
 }
 
 func BenchmarkIndex(b *testing.B) {
@@ -49,6 +65,7 @@ func TestBuild(t *testing.T) {
 	mockey.Mock(filepath.WalkDir).To(func(_ string, f fs.WalkDirFunc) error {
 		sys.ErrSuppress(f("", nil, errors.New("ko")))
 		sys.ErrSuppress(f("", DirEntry{name: "dir", isDir: true}, nil))
+		sys.ErrSuppress(f(".trash", DirEntry{name: ".trash", isDir: true}, nil))
 		sys.ErrSuppress(f("fname.txt", DirEntry{name: "", isDir: false}, nil))
 		return f("Artist - Title.mp3", DirEntry{name: "", isDir: false}, nil)
 	}).Build()
@@ -65,6 +82,7 @@ func TestBuild(t *testing.T) {
 	assert.Equal(t, 0, status)
 	assert.Equal(t, 1, index.Size())
 	assert.Equal(t, 1, index.Size(Offline))
+	assert.Equal(t, map[string]string{"id": "Artist - Title.mp3"}, index.Files())
 }
 
 func TestBuildWithProgress(t *testing.T) {
@@ -89,6 +107,140 @@ func TestBuildWithProgress(t *testing.T) {
 		paths = append(paths, p)
 	}
 	assert.Len(t, paths, 2)
+
+	// stored tracks are reported too, without being parsed again
+	indexed = make(chan string, 10)
+	assert.Nil(t, index.BuildWithProgress("path", indexed))
+	close(indexed)
+	assert.Len(t, indexed, 2)
+}
+
+func TestBuildNested(t *testing.T) {
+	t.Chdir(t.TempDir())
+	assert.Nil(t, os.MkdirAll(filepath.Join("Artist", "Album"), 0o755))
+	assert.Nil(t, os.MkdirAll(".trash", 0o755))
+	assert.Nil(t, os.MkdirAll("trash", 0o755))
+	assert.Nil(t, os.WriteFile(filepath.Join("Artist", "Album", "01 - Title.mp3"), []byte{}, 0o600))
+	assert.Nil(t, os.WriteFile(filepath.Join(".trash", "Artist - Title.mp3"), []byte{}, 0o600))
+	assert.Nil(t, os.WriteFile(filepath.Join("trash", "Artist - Title.mp3"), []byte{}, 0o600))
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(id3.Open).Return(&id3.Tag{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&id3.Tag{}, "userDefinedText")).Return("id").Build()
+	mockey.Mock(mockey.GetMethod(&id3v2.Tag{}, "Close")).Return(nil).Build()
+
+	// testing
+	assert.Nil(t, entity.SetLayout(entity.LayoutNested))
+	defer func() { assert.Nil(t, entity.SetLayout(entity.LayoutFlat)) }()
+	index := New()
+	index.Exclude("trash")
+	assert.Nil(t, index.Build("."))
+	assert.Equal(t, map[string]string{"id": filepath.Join("Artist", "Album", "01 - Title.mp3")}, index.Files())
+	status, ok := index.Get(&entity.Track{Title: "Title", Artists: []string{"Artist"}, Album: "Album", Number: 1})
+	assert.True(t, ok)
+	assert.Equal(t, Offline, status)
+	_, ok = index.Get(&entity.Track{Title: "Title", Artists: []string{"Artist"}, Album: "Other", Number: 1})
+	assert.False(t, ok)
+}
+
+func TestBuildMixedFormats(t *testing.T) {
+	t.Chdir(t.TempDir())
+	for _, name := range []string{"Artist - Title.mp3", "Artist - Song.flac", "Artist - Tune.m4a", "cover.jpg"} {
+		assert.Nil(t, os.WriteFile(name, []byte{}, 0o600))
+	}
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(id3.Open).Return(&id3.Tag{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&id3.Tag{}, "userDefinedText")).Return("id").Build()
+	mockey.Mock(mockey.GetMethod(&id3v2.Tag{}, "Close")).Return(nil).Build()
+	mockey.Mock(vorbis.Open).Return(&vorbis.Tag{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&vorbis.Tag{}, "SpotifyID")).Return("vorbis").Build()
+	mockey.Mock(mockey.GetMethod(cmd.FFmpegCmd{}, "Tags")).Return(map[string]string{"spotify_id": "ffmeta"}, nil).Build()
+
+	// testing
+	index := New()
+	assert.Nil(t, index.Build("."))
+	assert.Equal(t, map[string]string{
+		"id":     "Artist - Title.mp3",
+		"vorbis": "Artist - Song.flac",
+		"ffmeta": "Artist - Tune.m4a",
+	}, index.Files())
+	assert.Nil(t, entity.SetFormat(entity.FormatFLAC))
+	defer func() { assert.Nil(t, entity.SetFormat(entity.FormatMP3)) }()
+	status, ok := index.Get(&entity.Track{ID: "id", Title: "Title", Artists: []string{"Artist"}})
+	assert.True(t, ok)
+	assert.Equal(t, Offline, status)
+}
+
+func TestBuildSidecars(t *testing.T) {
+	t.Chdir(t.TempDir())
+	assert.Nil(t, os.MkdirAll("Artist", 0o755))
+	for _, name := range []string{
+		"Artist - Title.mp3", "Artist - Title.lrc", "Artist - Orphan.txt",
+		filepath.Join("Artist", "Untagged.flac"), filepath.Join("Artist", "Untagged.txt"),
+	} {
+		assert.Nil(t, os.WriteFile(name, []byte{}, 0o600))
+	}
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(id3.Open).Return(&id3.Tag{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&id3.Tag{}, "userDefinedText")).Return("id").Build()
+	mockey.Mock(mockey.GetMethod(&id3v2.Tag{}, "Close")).Return(nil).Build()
+	mockey.Mock(vorbis.Open).Return(&vorbis.Tag{}, nil).Build()
+
+	// testing
+	index := New()
+	assert.Nil(t, index.Build("."))
+	assert.Equal(t, map[string]string{"id": "Artist - Title.mp3"}, index.Files())
+	sidecars := index.Sidecars()
+	assert.Equal(t, map[string]string{
+		"Artist - Title.lrc":                    "Artist - Title.mp3",
+		"Artist - Orphan.txt":                   "",
+		filepath.Join("Artist", "Untagged.txt"): filepath.Join("Artist", "Untagged.flac"),
+	}, sidecars)
+	sidecars["Artist - Title.lrc"] = ""
+	assert.Equal(t, "Artist - Title.mp3", index.Sidecars()["Artist - Title.lrc"])
+
+	// sidecars only reflect the last walk
+	assert.Nil(t, os.Remove("Artist - Title.lrc"))
+	assert.Nil(t, index.Build("."))
+	assert.Len(t, index.Sidecars(), 2)
+}
+
+func TestBuildWalkFailure(t *testing.T) {
+	t.Chdir(t.TempDir())
+	assert.Error(t, New().Build("missing"))
+}
+
+func TestBuildVorbisFailure(t *testing.T) {
+	t.Chdir(t.TempDir())
+	assert.Nil(t, os.WriteFile("Artist - Title.opus", []byte("ID3"), 0o600))
+
+	// testing
+	index := New()
+	assert.Nil(t, index.Build("."))
+	assert.Error(t, index.Skipped()["Artist - Title.opus"])
+	assert.Empty(t, index.Files())
+}
+
+func TestBuildTagsFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(filepath.WalkDir).To(func(_ string, f fs.WalkDirFunc) error {
+		return f("fname.m4a", DirEntry{name: "", isDir: false}, nil)
+	}).Build()
+	mockey.Mock(mockey.GetMethod(cmd.FFmpegCmd{}, "Tags")).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	index := New()
+	assert.Nil(t, index.Build("path"))
+	assert.Len(t, index.Skipped(), 1)
+	for _, err := range index.Skipped() {
+		assert.EqualError(t, err, "ko")
+	}
 }
 
 func TestBuildOpenFailure(t *testing.T) {
@@ -100,7 +252,12 @@ func TestBuildOpenFailure(t *testing.T) {
 	mockey.Mock(id3.Open).Return(nil, errors.New("ko")).Build()
 
 	// testing
-	assert.EqualError(t, New().Build("path"), "ko")
+	index := New()
+	assert.Nil(t, index.Build("path"))
+	assert.Len(t, index.Skipped(), 1)
+	for _, err := range index.Skipped() {
+		assert.EqualError(t, err, "ko")
+	}
 }
 
 func TestBuildCloseFailure(t *testing.T) {
@@ -114,7 +271,12 @@ func TestBuildCloseFailure(t *testing.T) {
 	mockey.Mock(mockey.GetMethod(&id3v2.Tag{}, "Close")).Return(errors.New("ko")).Build()
 
 	// testing
-	assert.EqualError(t, New().Build("path"), "ko")
+	index := New()
+	assert.Nil(t, index.Build("path"))
+	assert.Len(t, index.Skipped(), 1)
+	for _, err := range index.Skipped() {
+		assert.EqualError(t, err, "ko")
+	}
 }
 
 func TestGetFallsBackToPath(t *testing.T) {
@@ -135,3 +297,49 @@ func TestGetPrefersIDOverPath(t *testing.T) {
 	assert.True(t, ok)
 	assert.Equal(t, Flush, status)
 }
+
+func TestKeyFromPath(t *testing.T) {
+	assert.Equal(t, keyFromPath("Artist - Title.mp3"), keyFromPath("./Artist - Title.mp3"))
+	assert.NotEqual(t, keyFromPath(filepath.Join("Artist", "Title.mp3")), keyFromPath("Artist - Title.mp3"))
+}
+
+func TestStatusName(t *testing.T) {
+	assert.Equal(t, "offline", StatusName(Offline))
+	assert.Equal(t, "online", StatusName(Online))
+	assert.Equal(t, "flush", StatusName(Flush))
+	assert.Equal(t, "installed", StatusName(Installed))
+	assert.Empty(t, StatusName(-1))
+}
+
+func TestCollision(t *testing.T) {
+	index := New()
+	index.SetID("id", Offline)
+	index.setFile("id", "Artist - Title.mp3")
+	index.Set(&entity.Track{ID: "other", Title: "Song", Artists: []string{"Artist"}}, Online)
+	index.Set(&entity.Track{ID: "another", Title: "Song", Artists: []string{"Artist"}}, Online)
+
+	owner, ok := index.Collision(&entity.Track{ID: "different-id", Title: "Title", Artists: []string{"Artist"}})
+	assert.True(t, ok)
+	assert.Equal(t, "id", owner)
+	owner, ok = index.Collision(&entity.Track{ID: "another", Title: "Song", Artists: []string{"Artist"}})
+	assert.True(t, ok)
+	assert.Equal(t, "other", owner)
+	_, ok = index.Collision(&entity.Track{ID: "id", Title: "Title", Artists: []string{"Artist"}})
+	assert.False(t, ok)
+	_, ok = index.Collision(&entity.Track{ID: "id", Title: "Unknown", Artists: []string{"Artist"}})
+	assert.False(t, ok)
+}
+
+func TestSetIfAbsent(t *testing.T) {
+	var (
+		index = New()
+		track = &entity.Track{ID: "id", Title: "Title", Artists: []string{"Artist"}}
+	)
+
+	status, ok := index.SetIfAbsent(track, Online)
+	assert.False(t, ok)
+	assert.Equal(t, Online, status)
+	status, ok = index.SetIfAbsent(track, Flush)
+	assert.True(t, ok)
+	assert.Equal(t, Online, status)
+}
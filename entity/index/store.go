@@ -0,0 +1,90 @@
+package index
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/streambinder/spotitube/sys"
+)
+
+const StoreBasename = "index.json"
+
+// store entries are keyed by absolute path and only trusted
+// as long as the file modification time and size match
+type storeEntry struct {
+	ModTime     time.Time `json:"mtime"`
+	Size        int64     `json:"size"`
+	ID          string    `json:"id,omitempty"`
+	UpstreamURL string    `json:"upstream_url,omitempty"`
+	Status      string    `json:"status,omitempty"`
+}
+
+func (index *Index) Load(path string) error {
+	store := make(map[string]*storeEntry)
+	if err := sys.JSONLoad(path, &store); err != nil {
+		return err
+	}
+
+	index.lock.Lock()
+	defer index.lock.Unlock()
+	index.store = store
+	return nil
+}
+
+func (index *Index) Save(path string) error {
+	index.lock.Lock()
+	defer index.lock.Unlock()
+	for _, entry := range index.store {
+		if status, ok := index.ids[keyFromID(entry.ID)]; len(entry.ID) > 0 && ok {
+			entry.Status = StatusName(status)
+		}
+	}
+	return sys.JSONSave(path, index.store)
+}
+
+// the persisted status carries over to the next build, except for
+// tracks installed by then, which are just previously synced ones
+func (entry *storeEntry) status(fallback int) int {
+	for status, name := range statusNames {
+		if name == entry.Status {
+			return sys.Ternary(status == Installed, Offline, status)
+		}
+	}
+	return fallback
+}
+
+// Forget drops the stored entries of the tracks under root, so that
+// they all get parsed again, leaving any other root untouched
+func (index *Index) Forget(root string) {
+	index.storePrune(sys.ErrWrap(root)(filepath.Abs(root)), nil)
+}
+
+func (index *Index) storeGet(path string, info fs.FileInfo) (*storeEntry, bool) {
+	index.lock.RLock()
+	defer index.lock.RUnlock()
+	entry, ok := index.store[path]
+	if !ok || !entry.ModTime.Equal(info.ModTime()) || entry.Size != info.Size() {
+		return nil, false
+	}
+	return entry, true
+}
+
+func (index *Index) storeSet(path string, entry *storeEntry) {
+	index.lock.Lock()
+	defer index.lock.Unlock()
+	index.store[path] = entry
+}
+
+// entries of files which are gone from root get dropped,
+// the ones belonging to any other root are left untouched
+func (index *Index) storePrune(root string, seen map[string]bool) {
+	index.lock.Lock()
+	defer index.lock.Unlock()
+	for path := range index.store {
+		if !seen[path] && (path == root || strings.HasPrefix(path, root+string(filepath.Separator))) {
+			delete(index.store, path)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package index
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bogem/id3v2/v2"
+	"github.com/bytedance/mockey"
+	"github.com/streambinder/spotitube/entity"
+	"github.com/streambinder/spotitube/entity/id3"
+	"github.com/stretchr/testify/assert"
+)
+
+type BrokenDirEntry struct {
+	DirEntry
+}
+
+func (e BrokenDirEntry) Info() (fs.FileInfo, error) {
+	return nil, errors.New("ko")
+}
+
+func BenchmarkStore(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		TestStore(&testing.T{})
+	}
+}
+
+func TestStore(t *testing.T) {
+	var (
+		parsed    = 0
+		storePath = filepath.Join(t.TempDir(), "store", StoreBasename)
+	)
+	t.Chdir(t.TempDir())
+	assert.Nil(t, os.WriteFile("Artist - Title.mp3", []byte{}, 0o600))
+	assert.Nil(t, os.WriteFile("Artist - Song.mp3", []byte{}, 0o600))
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(id3.Open).To(func(string, id3v2.Options) (*id3.Tag, error) {
+		parsed++
+		return &id3.Tag{}, nil
+	}).Build()
+	mockey.Mock(mockey.GetMethod(&id3.Tag{}, "userDefinedText")).Return("id").Build()
+	mockey.Mock(mockey.GetMethod(&id3v2.Tag{}, "Close")).Return(nil).Build()
+
+	// testing
+	index := New()
+	assert.Nil(t, index.Load(storePath))
+	assert.Nil(t, index.Build("."))
+	assert.Equal(t, 2, parsed)
+	assert.Nil(t, index.Save(storePath))
+
+	var store map[string]*storeEntry
+	data, err := os.ReadFile(storePath)
+	assert.Nil(t, err)
+	assert.Nil(t, json.Unmarshal(data, &store))
+	assert.Len(t, store, 2)
+	for _, entry := range store {
+		assert.Equal(t, "id", entry.ID)
+		assert.Equal(t, "id", entry.UpstreamURL)
+		assert.Equal(t, "offline", entry.Status)
+	}
+
+	// unchanged tracks are not parsed again
+	index = New()
+	assert.Nil(t, index.Load(storePath))
+	assert.Nil(t, index.Build("."))
+	assert.Equal(t, 2, parsed)
+	status, ok := index.Get(&entity.Track{ID: "id"})
+	assert.True(t, ok)
+	assert.Equal(t, Offline, status)
+
+	// changed tracks are, gone ones are dropped
+	assert.Nil(t, os.WriteFile("Artist - Title.mp3", []byte("changed"), 0o600))
+	assert.Nil(t, os.Remove("Artist - Song.mp3"))
+	assert.Nil(t, index.Build("."))
+	assert.Equal(t, 3, parsed)
+	assert.Len(t, index.store, 1)
+}
+
+func TestStoreLoadFailure(t *testing.T) {
+	storePath := filepath.Join(t.TempDir(), StoreBasename)
+	assert.Nil(t, os.WriteFile(storePath, []byte("wut"), 0o600))
+
+	// testing
+	assert.Error(t, New().Load(storePath))
+	assert.Error(t, New().Load(filepath.Dir(storePath)))
+}
+
+func TestStoreSaveFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(os.MkdirAll).Return(errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, New().Save(filepath.Join(t.TempDir(), StoreBasename)), "ko")
+}
+
+func TestStoreSaveMarshalFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(json.Marshal).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, New().Save(filepath.Join(t.TempDir(), StoreBasename)), "ko")
+}
+
+func TestBuildInfoFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(filepath.WalkDir).To(func(_ string, f fs.WalkDirFunc) error {
+		return f("fname.mp3", BrokenDirEntry{}, nil)
+	}).Build()
+
+	// testing
+	index := New()
+	assert.Nil(t, index.Build("path"))
+	assert.EqualError(t, index.Skipped()["fname.mp3"], "ko")
+}
+
+func TestStoreForget(t *testing.T) {
+	var (
+		root  = t.TempDir()
+		index = New()
+	)
+	index.storeSet(filepath.Join(root, "Artist - Title.mp3"), &storeEntry{ID: "id"})
+	index.storeSet(root+"more/Artist - Title.mp3", &storeEntry{ID: "id"})
+	index.storeSet("/other/Artist - Title.mp3", &storeEntry{ID: "id"})
+
+	// testing
+	t.Chdir(root)
+	index.Forget(".")
+	assert.Len(t, index.store, 2)
+	assert.NotContains(t, index.store, filepath.Join(root, "Artist - Title.mp3"))
+}
+
+func TestStoreStatus(t *testing.T) {
+	assert.Equal(t, Flush, (&storeEntry{Status: "flush"}).status(Offline))
+	assert.Equal(t, Offline, (&storeEntry{Status: "installed"}).status(Online))
+	assert.Equal(t, Online, (&storeEntry{}).status(Online))
+}
+
+func TestStoreStatusCarryOver(t *testing.T) {
+	var (
+		storePath = filepath.Join(t.TempDir(), StoreBasename)
+		track     = &entity.Track{ID: "id"}
+	)
+	t.Chdir(t.TempDir())
+	assert.Nil(t, os.WriteFile("Artist - Title.mp3", []byte{}, 0o600))
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(id3.Open).Return(&id3.Tag{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&id3.Tag{}, "userDefinedText")).Return("id").Build()
+	mockey.Mock(mockey.GetMethod(&id3v2.Tag{}, "Close")).Return(nil).Build()
+
+	// testing
+	index := New()
+	assert.Nil(t, index.Build("."))
+	index.SetID(track.ID, Flush)
+	assert.Nil(t, index.Save(storePath))
+
+	index = New()
+	assert.Nil(t, index.Load(storePath))
+	assert.Nil(t, index.Build("."))
+	status, ok := index.Get(track)
+	assert.True(t, ok)
+	assert.Equal(t, Flush, status)
+}
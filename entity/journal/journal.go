@@ -0,0 +1,129 @@
+package journal
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/streambinder/spotitube/entity"
+	"github.com/streambinder/spotitube/sys"
+)
+
+const (
+	Basename = "journal.json"
+
+	// retries are spaced exponentially, starting from backoffBase after
+	// the first failure and doubling on every subsequent one, up to backoffMax
+	backoffBase = 6 * time.Hour
+	backoffMax  = 30 * 24 * time.Hour
+)
+
+type Entry struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	Artist      string    `json:"artist"`
+	Reason      string    `json:"reason"`
+	Attempts    int       `json:"attempts"`
+	LastAttempt time.Time `json:"last_attempt"`
+}
+
+type Journal struct {
+	entries map[string]*Entry
+	lock    sync.RWMutex
+}
+
+func New() *Journal {
+	return &Journal{
+		entries: make(map[string]*Entry),
+		lock:    sync.RWMutex{},
+	}
+}
+
+func Load(path string) (*Journal, error) {
+	var (
+		journal = New()
+		entries []*Entry
+	)
+	if err := sys.JSONLoad(path, &entries); err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		journal.entries[entry.ID] = entry
+	}
+	return journal, nil
+}
+
+func (journal *Journal) Save(path string) error {
+	return sys.JSONSave(path, journal.Entries())
+}
+
+func (journal *Journal) Fail(track *entity.Track, reason string) {
+	journal.lock.Lock()
+	defer journal.lock.Unlock()
+
+	entry, ok := journal.entries[track.ID]
+	if !ok {
+		entry = &Entry{ID: track.ID}
+		journal.entries[track.ID] = entry
+	}
+	entry.Title = track.Title
+	entry.Artist = track.Artists[0]
+	entry.Reason = reason
+	entry.Attempts++
+	entry.LastAttempt = time.Now()
+}
+
+func (journal *Journal) Remove(id string) {
+	journal.lock.Lock()
+	defer journal.lock.Unlock()
+	delete(journal.entries, id)
+}
+
+// entries are sorted by last attempt, so that
+// the longest waiting ones come first
+func (journal *Journal) Entries() []*Entry {
+	journal.lock.RLock()
+	defer journal.lock.RUnlock()
+
+	entries := make([]*Entry, 0, len(journal.entries))
+	for _, entry := range journal.entries {
+		entries = append(entries, entry)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].LastAttempt.Equal(entries[j].LastAttempt) {
+			return entries[i].ID < entries[j].ID
+		}
+		return entries[i].LastAttempt.Before(entries[j].LastAttempt)
+	})
+	return entries
+}
+
+// due entries are the ones whose backoff window elapsed
+func (journal *Journal) Due(now time.Time) (entries []*Entry) {
+	for _, entry := range journal.Entries() {
+		if !now.Before(entry.LastAttempt.Add(Backoff(entry.Attempts))) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func (journal *Journal) Size() int {
+	journal.lock.RLock()
+	defer journal.lock.RUnlock()
+	return len(journal.entries)
+}
+
+func Backoff(attempts int) time.Duration {
+	if attempts <= 0 {
+		return 0
+	}
+
+	backoff := backoffBase
+	for i := 1; i < attempts; i++ {
+		if backoff *= 2; backoff >= backoffMax {
+			return backoffMax
+		}
+	}
+	return backoff
+}
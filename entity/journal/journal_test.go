@@ -0,0 +1,140 @@
+package journal
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bytedance/mockey"
+	"github.com/streambinder/spotitube/entity"
+	"github.com/streambinder/spotitube/sys"
+	"github.com/stretchr/testify/assert"
+)
+
+var track = &entity.Track{ID: "123", Title: "Title", Artists: []string{"Artist"}}
+
+func BenchmarkJournal(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		TestJournal(&testing.T{})
+	}
+}
+
+func TestJournal(t *testing.T) {
+	journal := New()
+	journal.Fail(track, "not found")
+	journal.Fail(track, "search failed")
+	journal.Fail(&entity.Track{ID: "456", Title: "Title", Artists: []string{"Artist"}}, "not found")
+	assert.Equal(t, 2, journal.Size())
+
+	entries := journal.Entries()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "123", entries[0].ID)
+	assert.Equal(t, "Title", entries[0].Title)
+	assert.Equal(t, "Artist", entries[0].Artist)
+	assert.Equal(t, "search failed", entries[0].Reason)
+	assert.Equal(t, 2, entries[0].Attempts)
+	assert.Equal(t, 1, entries[1].Attempts)
+
+	journal.Remove("123")
+	assert.Equal(t, 1, journal.Size())
+}
+
+func TestJournalEntriesSameAttempt(t *testing.T) {
+	now := time.Now()
+	journal := New()
+	journal.entries["b"] = &Entry{ID: "b", LastAttempt: now}
+	journal.entries["a"] = &Entry{ID: "a", LastAttempt: now}
+
+	entries := journal.Entries()
+	assert.Equal(t, "a", entries[0].ID)
+	assert.Equal(t, "b", entries[1].ID)
+}
+
+func TestJournalDue(t *testing.T) {
+	now := time.Now()
+	journal := New()
+	journal.entries["due"] = &Entry{ID: "due", Attempts: 1, LastAttempt: now.Add(-backoffBase)}
+	journal.entries["waiting"] = &Entry{ID: "waiting", Attempts: 2, LastAttempt: now.Add(-backoffBase)}
+
+	entries := journal.Due(now)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "due", entries[0].ID)
+}
+
+func TestJournalSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dir", Basename)
+	journal := New()
+	journal.Fail(track, "not found")
+	assert.Nil(t, journal.Save(path))
+
+	loaded, err := Load(path)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, loaded.Size())
+	assert.Equal(t, "not found", loaded.Entries()[0].Reason)
+}
+
+func TestJournalLoadNotExists(t *testing.T) {
+	journal, err := Load(filepath.Join(t.TempDir(), Basename))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, journal.Size())
+}
+
+func TestJournalLoadFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(os.ReadFile).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(Load(Basename)), "ko")
+}
+
+func TestJournalLoadUnmarshalFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(os.ReadFile).Return([]byte("{"), nil).Build()
+
+	// testing
+	assert.Error(t, sys.ErrOnly(Load(Basename)))
+}
+
+func TestJournalSaveMkdirFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(os.MkdirAll).Return(errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, New().Save(Basename), "ko")
+}
+
+func TestJournalSaveMarshalFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(os.MkdirAll).Return(nil).Build()
+	mockey.Mock(mockey.GetMethod(&Journal{}, "Entries")).Return([]*Entry{{LastAttempt: time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC)}}).Build()
+
+	// testing
+	assert.Error(t, New().Save(Basename))
+}
+
+func TestJournalSaveWriteFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(os.MkdirAll).Return(nil).Build()
+	mockey.Mock(os.WriteFile).To(func(string, []byte, fs.FileMode) error {
+		return errors.New("ko")
+	}).Build()
+
+	// testing
+	assert.EqualError(t, New().Save(Basename), "ko")
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, time.Duration(0), Backoff(0))
+	assert.Equal(t, backoffBase, Backoff(1))
+	assert.Equal(t, 2*backoffBase, Backoff(2))
+	assert.Equal(t, 4*backoffBase, Backoff(3))
+	assert.Equal(t, backoffMax, Backoff(100))
+}
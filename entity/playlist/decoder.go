@@ -0,0 +1,67 @@
+package playlist
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/streambinder/spotitube/sys"
+)
+
+// Decode parses the m3u or pls playlist at path into a playlist named after its
+// header (or its file name, if missing) and the paths of the tracks it references,
+// resolved against the folder the playlist is in
+func Decode(path string) (*Playlist, []string, error) {
+	var header func(string) string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".m3u":
+		header = m3uHeader
+	case ".pls":
+		header = plsHeader
+	default:
+		return nil, nil, errors.New("unsupported encoding")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		playlist   = &Playlist{}
+		references []string
+	)
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if name := header(line); len(name) > 0 && len(playlist.Name) == 0 {
+			playlist.Name = name
+		}
+		if reference := reference(line); len(reference) > 0 {
+			if !filepath.IsAbs(reference) {
+				reference = filepath.Join(filepath.Dir(path), reference)
+			}
+			references = append(references, filepath.Clean(reference))
+		}
+	}
+	playlist.Name = sys.Fallback(playlist.Name, sys.FileBaseStem(filepath.Base(path)))
+	return playlist, references, nil
+}
+
+func m3uHeader(line string) string {
+	if name, ok := strings.CutPrefix(line, "#PLAYLIST:"); ok {
+		return strings.TrimSpace(name)
+	}
+	return ""
+}
+
+// the standard pls header carries no name
+func plsHeader(line string) string {
+	if !strings.HasPrefix(line, "[") || !strings.HasSuffix(line, "]") {
+		return ""
+	}
+	if name := strings.TrimSpace(line[1 : len(line)-1]); !strings.EqualFold(name, "playlist") {
+		return name
+	}
+	return ""
+}
@@ -0,0 +1,65 @@
+package playlist
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bytedance/mockey"
+	"github.com/streambinder/spotitube/sys"
+	"github.com/stretchr/testify/assert"
+)
+
+func BenchmarkDecode(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		TestDecode(&testing.T{})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	path := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(path, "playlist.m3u"), []byte(`#EXTM3U
+#PLAYLIST:Playlist
+#EXTINF:0,Artist - Title
+Artist - Title.mp3
+/absolute/Artist - Song.mp3
+`), 0o600))
+	assert.Nil(t, os.WriteFile(filepath.Join(path, "named.pls"), []byte(`[Named]
+
+File1=Artist - Song.mp3
+Title1=Artist - Song
+Length1=0
+
+NumberOfEntries=1
+`), 0o600))
+	assert.Nil(t, os.WriteFile(filepath.Join(path, "unnamed.PLS"), []byte(`[playlist]
+File1=Artist - Song.mp3
+`), 0o600))
+
+	// testing
+	playlist, references, err := Decode(filepath.Join(path, "playlist.m3u"))
+	assert.Nil(t, err)
+	assert.Equal(t, "Playlist", playlist.Name)
+	assert.Equal(t, []string{filepath.Join(path, "Artist - Title.mp3"), "/absolute/Artist - Song.mp3"}, references)
+	playlist, references, err = Decode(filepath.Join(path, "named.pls"))
+	assert.Nil(t, err)
+	assert.Equal(t, "Named", playlist.Name)
+	assert.Equal(t, []string{filepath.Join(path, "Artist - Song.mp3")}, references)
+	playlist, _, err = Decode(filepath.Join(path, "unnamed.PLS"))
+	assert.Nil(t, err)
+	assert.Equal(t, "unnamed", playlist.Name)
+}
+
+func TestDecodeUnsupportedEncoding(t *testing.T) {
+	assert.EqualError(t, sys.ErrOnly(Decode("playlist.xspf")), "unsupported encoding")
+}
+
+func TestDecodeFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(os.ReadFile).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(Decode("playlist.m3u")), "ko")
+}
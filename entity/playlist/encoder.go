@@ -1,11 +1,22 @@
 package playlist
 
 import (
+	"bytes"
+	"os"
+
 	"github.com/streambinder/spotitube/entity"
 )
 
 type playlistEncoder interface {
 	init(string) error
 	Add(*entity.Track) error
+	Target() string
+	Changed() bool
 	Close() error
 }
+
+// changed tells whether writing data to target would alter it
+func changed(target string, data []byte) bool {
+	current, err := os.ReadFile(target)
+	return err != nil || !bytes.Equal(current, data)
+}
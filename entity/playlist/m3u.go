@@ -31,12 +31,20 @@ func (encoder *M3UEncoder) Add(track *entity.Track) error {
 			"#EXTINF:%s,%s\n%s\n",
 			strconv.Itoa(track.Duration),
 			sys.FileBaseStem(filepath.Base(track.Path().Final())),
-			filepath.Base(track.Path().Final()),
+			track.Path().Final(),
 		),
 	)...)
 	return nil
 }
 
+func (encoder *M3UEncoder) Target() string {
+	return encoder.target
+}
+
+func (encoder *M3UEncoder) Changed() bool {
+	return changed(encoder.target, encoder.data)
+}
+
 func (encoder *M3UEncoder) Close() error {
 	return os.WriteFile(encoder.target, encoder.data, 0o600)
 }
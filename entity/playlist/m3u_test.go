@@ -29,6 +29,8 @@ func TestM3U(t *testing.T) {
 	encoder := &M3UEncoder{}
 	assert.Nil(t, encoder.init(testPlaylist.Name))
 	assert.Nil(t, encoder.Add(testTrack))
+	assert.Equal(t, "playlist.m3u", encoder.Target())
+	assert.True(t, encoder.Changed())
 	assert.Nil(t, encoder.Close())
 	assert.Equal(t, `#EXTM3U
 #PLAYLIST:Playlist
@@ -12,6 +12,7 @@ type Playlist struct {
 	Name          string
 	Owner         string
 	Collaborative bool
+	SnapshotID    string
 	Tracks        []*entity.Track
 }
 
@@ -2,6 +2,7 @@ package playlist
 
 import (
 	"errors"
+	"os"
 	"testing"
 
 	"github.com/bytedance/mockey"
@@ -48,3 +49,13 @@ func TestEncoderInitFailure(t *testing.T) {
 func TestEncoderUnknown(t *testing.T) {
 	assert.Error(t, sys.ErrOnly(testPlaylist.Encoder("wut")), "unsupported encoding")
 }
+
+func TestChanged(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(os.ReadFile).Return([]byte("data"), nil).Build()
+
+	// testing
+	assert.False(t, changed("target", []byte("data")))
+	assert.True(t, changed("target", []byte("other")))
+}
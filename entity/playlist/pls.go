@@ -29,7 +29,7 @@ func (encoder *PLSEncoder) Add(track *entity.Track) error {
 		fmt.Sprintf(
 			"File%d=%s\nTitle%d=%s\nLength%d=%d\n\n",
 			encoder.entries,
-			filepath.Base(track.Path().Final()),
+			track.Path().Final(),
 			encoder.entries,
 			sys.FileBaseStem(filepath.Base(track.Path().Final())),
 			encoder.entries,
@@ -39,9 +39,20 @@ func (encoder *PLSEncoder) Add(track *entity.Track) error {
 	return nil
 }
 
+func (encoder *PLSEncoder) Target() string {
+	return encoder.target
+}
+
+func (encoder *PLSEncoder) Changed() bool {
+	return changed(encoder.target, encoder.content())
+}
+
 func (encoder *PLSEncoder) Close() error {
-	encoder.data = append(encoder.data, []byte(
+	return os.WriteFile(encoder.target, encoder.content(), 0o600)
+}
+
+func (encoder *PLSEncoder) content() []byte {
+	return append(append([]byte{}, encoder.data...), []byte(
 		fmt.Sprintf("NumberOfEntries=%d\n", encoder.entries),
 	)...)
-	return os.WriteFile(encoder.target, encoder.data, 0o600)
 }
@@ -29,6 +29,8 @@ func TestPLS(t *testing.T) {
 	encoder := &PLSEncoder{}
 	assert.Nil(t, encoder.init(testPlaylist.Name))
 	assert.Nil(t, encoder.Add(testTrack))
+	assert.Equal(t, "playlist.pls", encoder.Target())
+	assert.True(t, encoder.Changed())
 	assert.Nil(t, encoder.Close())
 	assert.Equal(t, `[Playlist]
 
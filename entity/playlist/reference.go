@@ -0,0 +1,47 @@
+package playlist
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// References collects the paths of the tracks, relative to path,
+// referenced by any m3u or pls playlist found in there
+func References(path string) (map[string]bool, error) {
+	references := make(map[string]bool)
+	for _, pattern := range []string{"*.m3u", "*.pls"} {
+		// only malformed patterns can fail globbing
+		matches, _ := filepath.Glob(filepath.Join(path, pattern))
+		for _, match := range matches {
+			data, err := os.ReadFile(match)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, line := range strings.Split(string(data), "\n") {
+				if reference := reference(strings.TrimSpace(line)); len(reference) > 0 {
+					references[filepath.Clean(reference)] = true
+				}
+			}
+		}
+	}
+	return references, nil
+}
+
+// m3u references are plain non-comment lines,
+// pls ones are in the form of FileN=reference
+func reference(line string) string {
+	if len(line) == 0 || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "[") {
+		return ""
+	}
+
+	key, value, ok := strings.Cut(line, "=")
+	if !ok {
+		return line
+	}
+	if strings.HasPrefix(key, "File") {
+		return value
+	}
+	return ""
+}
@@ -0,0 +1,52 @@
+package playlist
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bytedance/mockey"
+	"github.com/streambinder/spotitube/sys"
+	"github.com/stretchr/testify/assert"
+)
+
+func BenchmarkReferences(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		TestReferences(&testing.T{})
+	}
+}
+
+func TestReferences(t *testing.T) {
+	path := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(path, "playlist.m3u"), []byte(`#EXTM3U
+#PLAYLIST:Playlist
+#EXTINF:0,Artist - Title
+Artist - Title.mp3
+`), 0o600))
+	assert.Nil(t, os.WriteFile(filepath.Join(path, "playlist.pls"), []byte(`[Playlist]
+
+File1=Artist - Song.mp3
+Title1=Artist - Song
+Length1=0
+
+NumberOfEntries=1
+`), 0o600))
+
+	// testing
+	references, err := References(path)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]bool{"Artist - Title.mp3": true, "Artist - Song.mp3": true}, references)
+}
+
+func TestReferencesFailure(t *testing.T) {
+	path := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(path, "playlist.m3u"), []byte{}, 0o600))
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(os.ReadFile).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(References(path)), "ko")
+}
@@ -0,0 +1,49 @@
+package playlist
+
+import (
+	"sync"
+
+	"github.com/streambinder/spotitube/sys"
+)
+
+const SnapshotsBasename = "snapshots.json"
+
+// snapshots map every synchronized playlist, as targeted by the user,
+// to the snapshot of its contents as of its last synchronization
+type Snapshots struct {
+	ids  map[string]string
+	lock sync.RWMutex
+}
+
+func NewSnapshots() *Snapshots {
+	return &Snapshots{
+		ids:  make(map[string]string),
+		lock: sync.RWMutex{},
+	}
+}
+
+func LoadSnapshots(path string) (*Snapshots, error) {
+	snapshots := NewSnapshots()
+	if err := sys.JSONLoad(path, &snapshots.ids); err != nil {
+		return nil, err
+	}
+	return snapshots, nil
+}
+
+func (snapshots *Snapshots) Save(path string) error {
+	snapshots.lock.RLock()
+	defer snapshots.lock.RUnlock()
+	return sys.JSONSave(path, snapshots.ids)
+}
+
+func (snapshots *Snapshots) Get(target string) string {
+	snapshots.lock.RLock()
+	defer snapshots.lock.RUnlock()
+	return snapshots.ids[target]
+}
+
+func (snapshots *Snapshots) Set(target, snapshot string) {
+	snapshots.lock.Lock()
+	defer snapshots.lock.Unlock()
+	snapshots.ids[target] = snapshot
+}
@@ -0,0 +1,88 @@
+package playlist
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bytedance/mockey"
+	"github.com/streambinder/spotitube/sys"
+	"github.com/stretchr/testify/assert"
+)
+
+func BenchmarkSnapshots(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		TestSnapshotsSaveLoad(&testing.T{})
+	}
+}
+
+func TestSnapshotsSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dir", SnapshotsBasename)
+	snapshots := NewSnapshots()
+	snapshots.Set("playlist", "snapshot")
+	assert.Equal(t, "snapshot", snapshots.Get("playlist"))
+	assert.Empty(t, snapshots.Get("unknown"))
+	assert.Nil(t, snapshots.Save(path))
+
+	loaded, err := LoadSnapshots(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "snapshot", loaded.Get("playlist"))
+}
+
+func TestSnapshotsLoadNotExists(t *testing.T) {
+	snapshots, err := LoadSnapshots(filepath.Join(t.TempDir(), SnapshotsBasename))
+	assert.Nil(t, err)
+	assert.Empty(t, snapshots.Get("playlist"))
+}
+
+func TestSnapshotsLoadFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(os.ReadFile).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(LoadSnapshots(SnapshotsBasename)), "ko")
+}
+
+func TestSnapshotsLoadUnmarshalFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(os.ReadFile).Return([]byte("{"), nil).Build()
+
+	// testing
+	assert.Error(t, sys.ErrOnly(LoadSnapshots(SnapshotsBasename)))
+}
+
+func TestSnapshotsSaveMkdirFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(os.MkdirAll).Return(errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, NewSnapshots().Save(SnapshotsBasename), "ko")
+}
+
+func TestSnapshotsSaveMarshalFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(os.MkdirAll).Return(nil).Build()
+	mockey.Mock(json.Marshal).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, NewSnapshots().Save(SnapshotsBasename), "ko")
+}
+
+func TestSnapshotsSaveWriteFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(os.MkdirAll).Return(nil).Build()
+	mockey.Mock(os.WriteFile).To(func(string, []byte, fs.FileMode) error {
+		return errors.New("ko")
+	}).Build()
+
+	// testing
+	assert.EqualError(t, NewSnapshots().Save(SnapshotsBasename), "ko")
+}
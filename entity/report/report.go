@@ -0,0 +1,76 @@
+package report
+
+import (
+	"sync"
+
+	"github.com/streambinder/spotitube/entity"
+	"github.com/streambinder/spotitube/sys"
+)
+
+type Entry struct {
+	ID            string           `json:"id"`
+	Title         string           `json:"title"`
+	Artists       []string         `json:"artists"`
+	Status        string           `json:"status"`
+	UpstreamURL   string           `json:"upstream_url,omitempty"`
+	UpstreamScore int              `json:"upstream_score,omitempty"`
+	LyricsSource  string           `json:"lyrics_source,omitempty"`
+	LyricsSynced  bool             `json:"lyrics_synced"`
+	ArtworkSize   int              `json:"artwork_size"`
+	Durations     map[string]int64 `json:"durations_ms"` // per stage, in milliseconds
+	Error         string           `json:"error,omitempty"`
+	Track         *entity.Track    `json:"-"`
+}
+
+type Report struct {
+	entries map[string]*Entry
+	order   []string // entries are reported in the order they were fetched
+	lock    sync.Mutex
+}
+
+func New() *Report {
+	return &Report{
+		entries: make(map[string]*Entry),
+		order:   []string{},
+		lock:    sync.Mutex{},
+	}
+}
+
+// updates are serialized, so that every stage can
+// safely enrich the entry bound to the given track
+func (report *Report) Update(track *entity.Track, update ...func(*Entry)) {
+	report.lock.Lock()
+	defer report.lock.Unlock()
+
+	entry, ok := report.entries[track.ID]
+	if !ok {
+		entry = &Entry{
+			ID:        track.ID,
+			Title:     track.Title,
+			Artists:   track.Artists,
+			Durations: make(map[string]int64),
+			Track:     track,
+		}
+		report.entries[track.ID] = entry
+		report.order = append(report.order, track.ID)
+	}
+
+	for _, fn := range update {
+		fn(entry)
+	}
+}
+
+func (report *Report) Entries() []*Entry {
+	report.lock.Lock()
+	defer report.lock.Unlock()
+
+	entries := make([]*Entry, 0, len(report.order))
+	for _, id := range report.order {
+		entries = append(entries, report.entries[id])
+	}
+	return entries
+}
+
+func (report *Report) Save(path string) error {
+	return sys.JSONSave(path, report.Entries())
+}
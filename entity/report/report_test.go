@@ -0,0 +1,96 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bytedance/mockey"
+	"github.com/streambinder/spotitube/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+var track = &entity.Track{ID: "123", Title: "Title", Artists: []string{"Artist"}}
+
+func BenchmarkReport(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		TestReport(&testing.T{})
+	}
+}
+
+func TestReport(t *testing.T) {
+	report := New()
+	report.Update(&entity.Track{ID: "456", Title: "Title", Artists: []string{"Artist"}})
+	report.Update(track, func(entry *Entry) {
+		entry.UpstreamURL = "http://localhost/"
+		entry.UpstreamScore = 10
+	})
+	report.Update(track, func(entry *Entry) {
+		entry.Durations["decide"] = 100
+	})
+
+	entries := report.Entries()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "456", entries[0].ID)
+	assert.Equal(t, "123", entries[1].ID)
+	assert.Equal(t, "Title", entries[1].Title)
+	assert.Equal(t, []string{"Artist"}, entries[1].Artists)
+	assert.Equal(t, "http://localhost/", entries[1].UpstreamURL)
+	assert.Equal(t, 10, entries[1].UpstreamScore)
+	assert.Equal(t, int64(100), entries[1].Durations["decide"])
+	assert.Equal(t, track, entries[1].Track)
+}
+
+func TestReportSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dir", "report.json")
+	report := New()
+	report.Update(track, func(entry *Entry) {
+		entry.Status = "installed"
+		entry.Error = "ko"
+	})
+	assert.Nil(t, report.Save(path))
+
+	data, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	var entries []map[string]interface{}
+	assert.Nil(t, json.Unmarshal(data, &entries))
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "123", entries[0]["id"])
+	assert.Equal(t, "installed", entries[0]["status"])
+	assert.Equal(t, "ko", entries[0]["error"])
+	assert.NotContains(t, entries[0], "Track")
+}
+
+func TestReportSaveMkdirFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(os.MkdirAll).Return(errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, New().Save("report.json"), "ko")
+}
+
+func TestReportSaveMarshalFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(os.MkdirAll).Return(nil).Build()
+	mockey.Mock(json.Marshal).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, New().Save("report.json"), "ko")
+}
+
+func TestReportSaveWriteFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(os.MkdirAll).Return(nil).Build()
+	mockey.Mock(os.WriteFile).To(func(string, []byte, fs.FileMode) error {
+		return errors.New("ko")
+	}).Build()
+
+	// testing
+	assert.EqualError(t, New().Save("report.json"), "ko")
+}
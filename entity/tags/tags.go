@@ -0,0 +1,151 @@
+package tags
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bogem/id3v2/v2"
+	"github.com/streambinder/spotitube/entity"
+	"github.com/streambinder/spotitube/entity/ffmeta"
+	"github.com/streambinder/spotitube/entity/id3"
+	"github.com/streambinder/spotitube/entity/vorbis"
+	"github.com/streambinder/spotitube/lyrics"
+	"github.com/streambinder/spotitube/sys"
+)
+
+// format-agnostic view over local tracks tags
+type Tag interface {
+	SpotifyID() string
+	SetSpotifyID(string)
+	Title() string
+	SetTitle(string)
+	Artist() string
+	SetArtist(string)
+	Album() string
+	SetAlbum(string)
+	Year() string
+	SetYear(string)
+	TrackNumber() string
+	SetTrackNumber(string)
+	ArtworkURL() string
+	SetArtworkURL(string)
+	Duration() string
+	SetDuration(string)
+	UpstreamURL() string
+	SetUpstreamURL(string)
+	UnsynchronizedLyrics() string
+	SynchronizedLyrics() []lyrics.SyncedLine
+	SetLyrics(string, string)
+	AttachedPicture() (string, []byte)
+	SetAttachedPicture([]byte)
+	Save() error
+	Close() error
+}
+
+type Parse int
+
+const (
+	ParseAll       Parse = iota
+	ParseSpotifyID       // only what identifies the track, e.g. for indexing
+	ParseNone            // as fields are all about to be rewritten
+)
+
+const sniffSize = 12
+
+type backend struct {
+	magic      func(header []byte) bool
+	extensions []string
+	open       func(path string, parse Parse) (Tag, error)
+}
+
+// formats plug in here, the first one being the default
+var backends = []backend{
+	{
+		func(header []byte) bool { return bytes.HasPrefix(header, []byte("ID3")) },
+		[]string{entity.FormatMP3},
+		openID3,
+	},
+	{
+		func(header []byte) bool {
+			return bytes.HasPrefix(header, []byte("fLaC")) || bytes.HasPrefix(header, []byte("OggS"))
+		},
+		[]string{entity.FormatFLAC, entity.FormatOpus, "ogg", "oga"},
+		openVorbis,
+	},
+	{
+		func(header []byte) bool { return len(header) >= 8 && string(header[4:8]) == "ftyp" },
+		[]string{entity.FormatM4A, "mp4"},
+		openFFmeta,
+	},
+}
+
+func Open(path string, parse Parse) (Tag, error) {
+	return lookup(path).open(path, parse)
+}
+
+// backends are told by magic bytes first, then by extension
+// (e.g. for MP3 files with no tag yet), falling back to ID3
+// as tracks used to be MP3 only
+func lookup(path string) backend {
+	var header []byte
+	if file, err := os.Open(path); err == nil {
+		// shorter files are sniffed as far as they go
+		header = make([]byte, sniffSize)
+		read, _ := io.ReadFull(file, header)
+		header = header[:read]
+		sys.ErrSuppress(file.Close())
+	}
+	for _, backend := range backends {
+		if backend.magic(header) {
+			return backend
+		}
+	}
+
+	extension := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	for _, backend := range backends {
+		if slices.Contains(backend.extensions, extension) {
+			return backend
+		}
+	}
+	return backends[0]
+}
+
+func openID3(path string, parse Parse) (Tag, error) {
+	var (
+		tag *id3.Tag
+		err error
+	)
+	if parse == ParseSpotifyID {
+		tag, err = id3.OpenSpotifyID(path)
+	} else {
+		tag, err = id3.Open(path, id3v2.Options{Parse: parse == ParseAll})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+// comments are read upfront, whatever the parse mode
+func openVorbis(path string, _ Parse) (Tag, error) {
+	tag, err := vorbis.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+func openFFmeta(path string, parse Parse) (Tag, error) {
+	if parse == ParseNone {
+		return ffmeta.New(path), nil
+	}
+	tag, err := ffmeta.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
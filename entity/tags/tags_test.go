@@ -0,0 +1,126 @@
+package tags
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/bogem/id3v2/v2"
+	"github.com/bytedance/mockey"
+	"github.com/streambinder/spotitube/entity/ffmeta"
+	"github.com/streambinder/spotitube/entity/id3"
+	"github.com/streambinder/spotitube/entity/vorbis"
+	"github.com/streambinder/spotitube/sys"
+	"github.com/streambinder/spotitube/sys/cmd"
+	"github.com/stretchr/testify/assert"
+)
+
+func BenchmarkTags(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		TestLookup(&testing.T{})
+	}
+}
+
+func testBackend(t *testing.T, expected func(string, Parse) (Tag, error), actual backend) {
+	assert.Equal(t, reflect.ValueOf(expected).Pointer(), reflect.ValueOf(actual.open).Pointer())
+}
+
+func TestLookup(t *testing.T) {
+	folder := t.TempDir()
+	for name, data := range map[string]string{
+		"tagged.flac":  "ID3\x04\x00",
+		"track.dat":    "fLaC\x00\x00\x00\x22",
+		"track.bin":    "OggS\x00\x02",
+		"track":        "\x00\x00\x00\x20ftypM4A ",
+		"untagged.mp3": "\xff\xfb\x90\x00",
+	} {
+		assert.Nil(t, os.WriteFile(filepath.Join(folder, name), []byte(data), 0o600))
+	}
+
+	// magic bytes win over extensions
+	testBackend(t, openID3, lookup(filepath.Join(folder, "tagged.flac")))
+	testBackend(t, openVorbis, lookup(filepath.Join(folder, "track.dat")))
+	testBackend(t, openVorbis, lookup(filepath.Join(folder, "track.bin")))
+	testBackend(t, openFFmeta, lookup(filepath.Join(folder, "track")))
+	testBackend(t, openID3, lookup(filepath.Join(folder, "untagged.mp3")))
+	testBackend(t, openVorbis, lookup(filepath.Join(folder, "missing.OPUS")))
+	testBackend(t, openFFmeta, lookup(filepath.Join(folder, "missing.m4a")))
+	testBackend(t, openID3, lookup(filepath.Join(folder, "missing")))
+}
+
+func TestOpenID3(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	var parsed []bool
+	mockey.Mock(id3v2.Open).To(func(_ string, options id3v2.Options) (*id3v2.Tag, error) {
+		parsed = append(parsed, options.Parse && len(options.ParseFrames) == 0)
+		return id3v2.NewEmptyTag(), nil
+	}).Build()
+
+	// testing
+	for _, parse := range []Parse{ParseAll, ParseSpotifyID, ParseNone} {
+		tag, err := Open("track.mp3", parse)
+		assert.Nil(t, err)
+		assert.IsType(t, &id3.Tag{}, tag)
+	}
+	assert.Equal(t, []bool{true, false, false}, parsed)
+}
+
+func TestOpenID3Failure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(id3v2.Open).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	tag, err := Open("track.mp3", ParseAll)
+	assert.EqualError(t, err, "ko")
+	assert.Nil(t, tag)
+}
+
+func TestOpenVorbis(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(vorbis.Open).Return(&vorbis.Tag{}, nil).Build()
+
+	// testing
+	tag, err := Open("track.flac", ParseNone)
+	assert.Nil(t, err)
+	assert.IsType(t, &vorbis.Tag{}, tag)
+}
+
+func TestOpenVorbisFailure(t *testing.T) {
+	// testing
+	tag, err := Open(filepath.Join(t.TempDir(), "missing.opus"), ParseAll)
+	assert.Error(t, err)
+	assert.Nil(t, tag)
+}
+
+func TestOpenFFmeta(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(cmd.FFmpegCmd{}, "Tags")).Return(map[string]string{"spotify_id": "id"}, nil).Build()
+
+	// testing
+	tag, err := Open("track.m4a", ParseSpotifyID)
+	assert.Nil(t, err)
+	assert.IsType(t, &ffmeta.Tag{}, tag)
+	assert.Equal(t, "id", tag.SpotifyID())
+
+	tag, err = Open("track.m4a", ParseNone)
+	assert.Nil(t, err)
+	assert.Empty(t, tag.SpotifyID())
+}
+
+func TestOpenFFmetaFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(cmd.FFmpegCmd{}, "Tags")).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	tag, err := Open("track.m4a", ParseAll)
+	assert.EqualError(t, err, "ko")
+	assert.Nil(t, tag)
+	assert.Nil(t, sys.ErrOnly(Open("track.m4a", ParseNone)))
+}
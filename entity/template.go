@@ -0,0 +1,67 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/streambinder/spotitube/sys"
+)
+
+// placeholders are in the form of {field} or {field:width},
+// the latter only applying to numeric fields (e.g. {number:02})
+var templatePlaceholder = regexp.MustCompile(`\{(\w+)(?::(\d+))?\}`)
+
+var templateFields = map[string]func(*Track) interface{}{
+	"id":           func(track *Track) interface{} { return track.ID },
+	"title":        func(track *Track) interface{} { return track.Title },
+	"song":         func(track *Track) interface{} { return track.Song() },
+	"artist":       func(track *Track) interface{} { return track.Artists[0] },
+	"artists":      func(track *Track) interface{} { return strings.Join(track.Artists, ", ") },
+	"album":        func(track *Track) interface{} { return track.Album },
+	"album_artist": func(track *Track) interface{} { return sys.Fallback(track.AlbumArtist, track.Artists[0]) },
+	"number":       func(track *Track) interface{} { return track.Number },
+	"year":         func(track *Track) interface{} { return track.Year },
+	"duration":     func(track *Track) interface{} { return track.Duration },
+}
+
+// path template, when set, takes precedence over the layout
+var trackTemplate string
+
+func SetPathTemplate(template string) error {
+	for _, match := range templatePlaceholder.FindAllStringSubmatch(template, -1) {
+		field, ok := templateFields[match[1]]
+		if !ok {
+			return errors.New("unknown path template field: " + match[1])
+		}
+		if _, numeric := field(&Track{Artists: []string{""}}).(int); len(match[2]) > 0 && !numeric {
+			return errors.New("path template field does not support width: " + match[1])
+		}
+	}
+	trackTemplate = template
+	return nil
+}
+
+// every segment gets legalized on its own, empty
+// (or relative) ones are dropped altogether
+func renderPathTemplate(template string, track *Track) string {
+	var segments []string
+	for _, segment := range strings.Split(template, "/") {
+		segment = templatePlaceholder.ReplaceAllStringFunc(segment, func(placeholder string) string {
+			match := templatePlaceholder.FindStringSubmatch(placeholder)
+			value := templateFields[match[1]](track)
+			if len(match[2]) > 0 {
+				return fmt.Sprintf("%"+match[2]+"d", value)
+			}
+			return fmt.Sprint(value)
+		})
+		segment = strings.TrimSpace(sys.LegalizeFilename(segment))
+		if segment == "" || segment == "." || segment == ".." {
+			continue
+		}
+		segments = append(segments, segment)
+	}
+	return fmt.Sprintf("%s.%s", filepath.Join(segments...), trackFormat)
+}
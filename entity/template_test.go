@@ -0,0 +1,40 @@
+package entity
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func BenchmarkTemplate(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		TestPathTemplate(&testing.T{})
+	}
+}
+
+func TestPathTemplate(t *testing.T) {
+	assert.Nil(t, SetPathTemplate("{album_artist}/{year} - {album}/{number:02} {title}"))
+	defer func() { assert.Nil(t, SetPathTemplate("")) }()
+
+	track := &Track{
+		ID:          "123",
+		Title:       "Title: Part 1",
+		Artists:     []string{"Artist", "Featuring"},
+		Album:       "Album",
+		AlbumArtist: "AC/DC",
+		Number:      3,
+		Year:        1970,
+	}
+	assert.Equal(t, filepath.Join("ACDC", "1970 - Album", "03 Title Part 1.mp3"), track.Path().Final())
+
+	// album artist falls back to the first artist, empty segments are dropped
+	assert.Nil(t, SetPathTemplate("/{album_artist}/{album}/../{artists} - {song} [{id}, {duration:3}]"))
+	track.AlbumArtist, track.Album, track.Duration = "", "", 60
+	assert.Equal(t, filepath.Join("Artist", "Artist, Featuring - Title Part 1 [123,  60].mp3"), track.Path().Final())
+}
+
+func TestPathTemplateFailure(t *testing.T) {
+	assert.EqualError(t, SetPathTemplate("{artist}/{wut}"), "unknown path template field: wut")
+	assert.EqualError(t, SetPathTemplate("{artist:02}"), "path template field does not support width: artist")
+}
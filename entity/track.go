@@ -1,8 +1,11 @@
 package entity
 
 import (
+	"errors"
 	"fmt"
 	"path"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/gosimple/slug"
@@ -19,6 +22,7 @@ type Track struct {
 	Title       string
 	Artists     []string
 	Album       string
+	AlbumArtist string // first album artist, if any
 	Artwork     Artwork
 	Duration    int // in seconds
 	Lyrics      string
@@ -32,11 +36,67 @@ type TrackPath struct {
 }
 
 const (
-	TrackFormat   = "mp3"
+	FormatMP3     = "mp3"
+	FormatFLAC    = "flac"
+	FormatOpus    = "opus"
+	FormatM4A     = "m4a"
 	ArtworkFormat = "jpg"
 	LyricsFormat  = "txt"
+	SyncedFormat  = "lrc" // synced lyrics sidecars
+
+	LayoutFlat   = "flat"   // Artist - Title.mp3
+	LayoutNested = "nested" // Artist/Album/NN - Title.mp3
 )
 
+// final paths get computed all over the place
+// out of bare tracks, hence layout is process-wide
+var trackLayout = LayoutFlat
+
+// as for the layout, the output format is process-wide,
+// while any supported format is recognised as a track
+var (
+	trackFormat  = FormatMP3
+	trackFormats = []string{FormatMP3, FormatFLAC, FormatOpus, FormatM4A}
+)
+
+func SetFormat(format string) error {
+	if !slices.Contains(trackFormats, format) {
+		return errors.New("unsupported format: " + format)
+	}
+	trackFormat = format
+	return nil
+}
+
+func TrackFormat() string {
+	return trackFormat
+}
+
+func TrackFormats() []string {
+	return slices.Clone(trackFormats)
+}
+
+// tells whether the given path is a track in any supported format
+func IsTrack(path string) bool {
+	return slices.Contains(trackFormats, strings.ToLower(strings.TrimPrefix(filepath.Ext(path), ".")))
+}
+
+// tells whether the given path may be a lyrics sidecar,
+// as only the ones sitting beside a track actually are
+func IsSidecar(path string) bool {
+	extension := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	return extension == SyncedFormat || extension == LyricsFormat
+}
+
+func SetLayout(layout string) error {
+	switch layout {
+	case LayoutFlat, LayoutNested:
+		trackLayout = layout
+		return nil
+	default:
+		return errors.New("unsupported layout: " + layout)
+	}
+}
+
 // certain track titles include the variant description,
 // this functions aims to strip out that part:
 // > Title: Name - Acoustic
@@ -58,12 +118,32 @@ func (track *Track) Path() TrackPath {
 }
 
 func (trackPath TrackPath) Final() string {
-	return sys.LegalizeFilename(fmt.Sprintf("%s - %s.%s", trackPath.track.Artists[0], trackPath.track.Title, TrackFormat))
+	if len(trackTemplate) > 0 {
+		return renderPathTemplate(trackTemplate, trackPath.track)
+	}
+	if trackLayout == LayoutNested {
+		filename := fmt.Sprintf("%s.%s", trackPath.track.Title, trackFormat)
+		if trackPath.track.Number > 0 {
+			filename = fmt.Sprintf("%02d - %s", trackPath.track.Number, filename)
+		}
+		// albumless tracks sit straight in the artist folder
+		return filepath.Join(
+			sys.LegalizeFilename(trackPath.track.Artists[0]),
+			sys.LegalizeFilename(trackPath.track.Album),
+			sys.LegalizeFilename(filename),
+		)
+	}
+	return sys.LegalizeFilename(fmt.Sprintf("%s - %s.%s", trackPath.track.Artists[0], trackPath.track.Title, trackFormat))
+}
+
+// lyrics sidecars share the final path, but the extension
+func (trackPath TrackPath) Sidecar(synced bool) string {
+	return sys.FileBaseStem(trackPath.Final()) + "." + sys.Ternary(synced, SyncedFormat, LyricsFormat)
 }
 
 func (trackPath TrackPath) Download() string {
 	return sys.CacheFile(
-		sys.LegalizeFilename(fmt.Sprintf("%s.%s", slug.Make(trackPath.track.ID), TrackFormat)),
+		sys.LegalizeFilename(fmt.Sprintf("%s.%s", slug.Make(trackPath.track.ID), trackFormat)),
 	)
 }
 
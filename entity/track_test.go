@@ -3,6 +3,7 @@ package entity
 import (
 	"fmt"
 	"path"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -12,6 +13,7 @@ func BenchmarkTrack(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		TestSong(&testing.T{})
 		TestPath(&testing.T{})
+		TestPathNested(&testing.T{})
 	}
 }
 
@@ -30,10 +32,10 @@ func TestPath(t *testing.T) {
 		Artwork: Artwork{URL: "http://domain.tld/123"},
 	}
 	assert.Equal(t,
-		fmt.Sprintf("%s - %s.%s", track.Path().track.Artists[0], track.Path().track.Title, TrackFormat),
+		fmt.Sprintf("%s - %s.%s", track.Path().track.Artists[0], track.Path().track.Title, TrackFormat()),
 		path.Base(track.Path().Final()))
 	assert.Equal(t,
-		fmt.Sprintf("%s.%s", track.Path().track.ID, TrackFormat),
+		fmt.Sprintf("%s.%s", track.Path().track.ID, TrackFormat()),
 		path.Base(track.Path().Download()))
 	assert.Equal(t,
 		fmt.Sprintf("%s.%s", path.Base(track.Path().track.Artwork.URL), ArtworkFormat),
@@ -42,3 +44,67 @@ func TestPath(t *testing.T) {
 		fmt.Sprintf("%s.%s", track.Path().track.ID, LyricsFormat),
 		path.Base(track.Path().Lyrics()))
 }
+
+func TestPathNested(t *testing.T) {
+	assert.Nil(t, SetLayout(LayoutNested))
+	defer func() { assert.Nil(t, SetLayout(LayoutFlat)) }()
+
+	assert.Equal(t,
+		filepath.Join("ACDC", "Album", "03 - Title.mp3"),
+		(&Track{Title: "Title", Artists: []string{"AC/DC"}, Album: "Album", Number: 3}).Path().Final())
+	assert.Equal(t,
+		filepath.Join("Artist", "Title.mp3"),
+		(&Track{Title: "Title", Artists: []string{"Artist"}}).Path().Final())
+}
+
+func TestSetLayout(t *testing.T) {
+	assert.EqualError(t, SetLayout("wut"), "unsupported layout: wut")
+}
+
+func TestPathFormat(t *testing.T) {
+	assert.Nil(t, SetFormat(FormatFLAC))
+	defer func() { assert.Nil(t, SetFormat(FormatMP3)) }()
+
+	assert.Equal(t, FormatFLAC, TrackFormat())
+	assert.Equal(t, "Artist - Title.flac", (&Track{Title: "Title", Artists: []string{"Artist"}}).Path().Final())
+	assert.Equal(t, "id.flac", filepath.Base((&Track{ID: "id"}).Path().Download()))
+}
+
+func TestSetFormat(t *testing.T) {
+	assert.EqualError(t, SetFormat("wav"), "unsupported format: wav")
+	assert.Equal(t, FormatMP3, TrackFormat())
+}
+
+func TestTrackFormats(t *testing.T) {
+	formats := TrackFormats()
+	assert.Equal(t, []string{FormatMP3, FormatFLAC, FormatOpus, FormatM4A}, formats)
+	formats[0] = "wav"
+	assert.Equal(t, FormatMP3, TrackFormats()[0])
+}
+
+func TestIsTrack(t *testing.T) {
+	for _, path := range []string{"a.mp3", "a.flac", "dir/a.opus", "a.M4A"} {
+		assert.True(t, IsTrack(path), path)
+	}
+	for _, path := range []string{"a.jpg", "a.m3u", "mp3", "a.lrc"} {
+		assert.False(t, IsTrack(path), path)
+	}
+}
+
+func TestIsSidecar(t *testing.T) {
+	for _, path := range []string{"a.lrc", "dir/a.txt", "a.LRC"} {
+		assert.True(t, IsSidecar(path), path)
+	}
+	for _, path := range []string{"a.mp3", "a.jpg", "lrc"} {
+		assert.False(t, IsSidecar(path), path)
+	}
+}
+
+func TestPathSidecar(t *testing.T) {
+	assert.Nil(t, SetFormat(FormatFLAC))
+	defer func() { assert.Nil(t, SetFormat(FormatMP3)) }()
+
+	track := &Track{Title: "Title", Artists: []string{"Artist"}}
+	assert.Equal(t, "Artist - Title.lrc", track.Path().Sidecar(true))
+	assert.Equal(t, "Artist - Title.txt", track.Path().Sidecar(false))
+}
@@ -0,0 +1,94 @@
+package vorbis
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/streambinder/spotitube/sys"
+)
+
+const (
+	magicFLAC = "fLaC"
+
+	flacBlockComment = 4
+	flacBlockPicture = 6
+	flacBlockLast    = 0x80
+	flacBlockMaxSize = 1<<24 - 1
+)
+
+var errFLAC = errors.New("corrupted flac metadata")
+
+type flacBlock struct {
+	kind byte
+	data []byte
+}
+
+// metadata blocks other than comments and pictures
+// are kept as they are, in their original order
+type flac struct {
+	blocks []flacBlock
+	size   int // magic and metadata blocks, up to the audio frames
+}
+
+func (tag *Tag) readFLAC(reader *bufio.Reader) error {
+	sys.ErrSuppress(sys.ErrOnly(reader.Discard(len(magicFLAC))))
+	stream := &flac{size: len(magicFLAC)}
+	for last := false; !last; {
+		var header [4]byte
+		if _, err := io.ReadFull(reader, header[:]); err != nil {
+			return errFLAC
+		}
+		data := make([]byte, int(header[1])<<16|int(header[2])<<8|int(header[3]))
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return errFLAC
+		}
+		stream.size += len(header) + len(data)
+		last = header[0]&flacBlockLast != 0
+
+		switch kind := header[0] &^ flacBlockLast; kind {
+		case flacBlockComment:
+			vendor, fields, err := decodeComment(data)
+			if err != nil {
+				return err
+			}
+			tag.vendor, tag.fields = vendor, append(tag.fields, fields...)
+		case flacBlockPicture:
+			tag.pictures = append(tag.pictures, data)
+		default:
+			stream.blocks = append(stream.blocks, flacBlock{kind, data})
+		}
+	}
+	tag.stream = stream
+	return nil
+}
+
+func (stream *flac) encode(tag *Tag, data []byte) ([]byte, error) {
+	if len(data) < stream.size || string(data[:len(magicFLAC)]) != magicFLAC {
+		return nil, errFLAC
+	}
+
+	blocks := append(stream.blocks[:len(stream.blocks):len(stream.blocks)],
+		flacBlock{flacBlockComment, encodeComment(tag.vendor, tag.fields)})
+	for _, picture := range tag.pictures {
+		blocks = append(blocks, flacBlock{flacBlockPicture, picture})
+	}
+
+	var buffer bytes.Buffer
+	buffer.WriteString(magicFLAC)
+	for index, block := range blocks {
+		if len(block.data) > flacBlockMaxSize {
+			return nil, errors.New("flac metadata block too large")
+		}
+		header := uint32(block.kind)<<24 | uint32(len(block.data))
+		if index == len(blocks)-1 {
+			header |= flacBlockLast << 24
+		}
+		buffer.Write(binary.BigEndian.AppendUint32(nil, header))
+		buffer.Write(block.data)
+	}
+	buffer.Write(data[stream.size:])
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,82 @@
+package vorbis
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/streambinder/spotitube/sys"
+	"github.com/stretchr/testify/assert"
+)
+
+func BenchmarkFLAC(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		TestReadFLAC(&testing.T{})
+	}
+}
+
+func testFLACBlock(kind byte, data []byte, last bool) []byte {
+	if last {
+		kind |= flacBlockLast
+	}
+	return append([]byte{kind, byte(len(data) >> 16), byte(len(data) >> 8), byte(len(data))}, data...)
+}
+
+// stream info, comments and padding, followed by fake frames
+func testFLAC(t *testing.T, fields ...string) string {
+	var buffer bytes.Buffer
+	buffer.WriteString(magicFLAC)
+	buffer.Write(testFLACBlock(0, make([]byte, 34), false))
+	buffer.Write(testFLACBlock(flacBlockComment, encodeComment("vendor", fields), false))
+	buffer.Write(testFLACBlock(1, make([]byte, 8), true))
+	buffer.WriteString("frames")
+
+	path := filepath.Join(t.TempDir(), "track.flac")
+	assert.Nil(t, os.WriteFile(path, buffer.Bytes(), 0o600))
+	return path
+}
+
+func testReadFLAC(data ...[]byte) (*Tag, error) {
+	tag := &Tag{}
+	return tag, tag.readFLAC(bufioReader(bytes.Join(data, nil)))
+}
+
+func TestReadFLAC(t *testing.T) {
+	tag, err := testReadFLAC([]byte(magicFLAC),
+		testFLACBlock(0, make([]byte, 34), false),
+		testFLACBlock(flacBlockPicture, encodePicture("image/png", []byte("picture")), false),
+		testFLACBlock(flacBlockComment, encodeComment("vendor", []string{"TITLE=Title"}), true),
+		[]byte("frames"))
+	assert.Nil(t, err)
+	assert.Equal(t, "Title", tag.Title())
+	mimeType, picture := tag.AttachedPicture()
+	assert.Equal(t, "image/png", mimeType)
+	assert.Equal(t, []byte("picture"), picture)
+	assert.Equal(t, 4+38+4+len(tag.pictures[0])+4+len(encodeComment("vendor", []string{"TITLE=Title"})), tag.stream.(*flac).size)
+	assert.Len(t, tag.stream.(*flac).blocks, 1)
+}
+
+func TestReadFLACFailure(t *testing.T) {
+	for _, data := range [][]byte{
+		[]byte(magicFLAC + "\x00\x00"),
+		[]byte(magicFLAC + "\x80\x00\x00\x0a\x00"),
+	} {
+		assert.Equal(t, errFLAC, sys.ErrOnly(testReadFLAC(data)))
+	}
+	assert.Equal(t, errComment, sys.ErrOnly(testReadFLAC([]byte(magicFLAC), testFLACBlock(flacBlockComment, []byte{1}, true))))
+}
+
+func TestEncodeFLACTooLarge(t *testing.T) {
+	tag, err := testReadFLAC([]byte(magicFLAC), testFLACBlock(0, make([]byte, 34), true))
+	assert.Nil(t, err)
+	tag.pictures = [][]byte{make([]byte, flacBlockMaxSize+1)}
+	assert.EqualError(t, sys.ErrOnly(tag.stream.encode(tag, []byte(magicFLAC+"\x80\x00\x00\x22"+string(make([]byte, 34))))), "flac metadata block too large")
+}
+
+func TestEncodeFLACMismatch(t *testing.T) {
+	tag, err := testReadFLAC([]byte(magicFLAC), testFLACBlock(0, make([]byte, 34), true))
+	assert.Nil(t, err)
+	assert.Equal(t, errFLAC, sys.ErrOnly(tag.stream.encode(tag, []byte(magicFLAC))))
+	assert.Equal(t, errFLAC, sys.ErrOnly(tag.stream.encode(tag, make([]byte, 64))))
+}
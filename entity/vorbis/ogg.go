@@ -0,0 +1,264 @@
+package vorbis
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+)
+
+const (
+	magicOgg = "OggS"
+
+	oggHeaderSize    = 27
+	oggFlagContinued = 0x01
+	oggFlagFirst     = 0x02
+	oggMaxSegments   = 255
+	oggMaxLacing     = 255
+	oggGranuleNone   = ^uint64(0) // no packet ends on the page
+	oggCRCPolynomial = 0x04c11db7
+)
+
+var (
+	errOgg      = errors.New("corrupted ogg stream")
+	oggCodecs   = []oggCodec{{"OpusHead", "OpusTags", false, 2}, {"\x01vorbis", "\x03vorbis", true, 3}}
+	oggCRCTable = func() (table [256]uint32) {
+		for index := range table {
+			crc := uint32(index) << 24
+			for range 8 {
+				if crc&0x80000000 != 0 {
+					crc = crc<<1 ^ oggCRCPolynomial
+				} else {
+					crc <<= 1
+				}
+			}
+			table[index] = crc
+		}
+		return table
+	}()
+)
+
+// codecs are told apart by their identification header,
+// the comment one follows, then any other (e.g. Vorbis setup)
+type oggCodec struct {
+	identification string
+	comment        string
+	framing        bool // trailing framing bit after the comments
+	headers        int  // number of header packets
+}
+
+type oggPage struct {
+	flags    byte
+	granule  uint64
+	serial   uint32
+	sequence uint32
+	segments []byte // lacing values
+	data     []byte
+}
+
+type ogg struct {
+	codec   oggCodec
+	serial  uint32
+	packets [][]byte // header packets
+	pages   int      // header pages
+	size    int      // header pages, up to the audio ones
+}
+
+func readOggPage(reader io.Reader) (*oggPage, error) {
+	var header [oggHeaderSize]byte
+	if _, err := io.ReadFull(reader, header[:]); err != nil || string(header[:len(magicOgg)]) != magicOgg {
+		return nil, errOgg
+	}
+
+	page := &oggPage{
+		flags:    header[5],
+		granule:  binary.LittleEndian.Uint64(header[6:]),
+		serial:   binary.LittleEndian.Uint32(header[14:]),
+		sequence: binary.LittleEndian.Uint32(header[18:]),
+		segments: make([]byte, header[26]),
+	}
+	if _, err := io.ReadFull(reader, page.segments); err != nil {
+		return nil, errOgg
+	}
+	size := 0
+	for _, lacing := range page.segments {
+		size += int(lacing)
+	}
+	page.data = make([]byte, size)
+	if _, err := io.ReadFull(reader, page.data); err != nil {
+		return nil, errOgg
+	}
+	return page, nil
+}
+
+func (page *oggPage) size() int {
+	return oggHeaderSize + len(page.segments) + len(page.data)
+}
+
+func (page *oggPage) encode() []byte {
+	buffer := make([]byte, 0, page.size())
+	buffer = append(buffer, magicOgg...)
+	buffer = append(buffer, 0, page.flags)
+	buffer = binary.LittleEndian.AppendUint64(buffer, page.granule)
+	buffer = binary.LittleEndian.AppendUint32(buffer, page.serial)
+	buffer = binary.LittleEndian.AppendUint32(buffer, page.sequence)
+	buffer = binary.LittleEndian.AppendUint32(buffer, 0) // checksum gets computed on the page with it zeroed
+	buffer = append(buffer, byte(len(page.segments)))
+	buffer = append(buffer, page.segments...)
+	buffer = append(buffer, page.data...)
+	binary.LittleEndian.PutUint32(buffer[22:], oggChecksum(buffer))
+	return buffer
+}
+
+func oggChecksum(data []byte) (crc uint32) {
+	for _, value := range data {
+		crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^value]
+	}
+	return crc
+}
+
+// lays the given packets out on as many pages as needed,
+// the last one being flushed as soon as the last packet ends
+func oggPaginate(serial, sequence uint32, packets [][]byte) []*oggPage {
+	var (
+		pages []*oggPage
+		page  = &oggPage{granule: oggGranuleNone, serial: serial, sequence: sequence}
+	)
+	for _, packet := range packets {
+		for offset := 0; ; {
+			if len(page.segments) == oggMaxSegments {
+				pages = append(pages, page)
+				page = &oggPage{granule: oggGranuleNone, serial: serial, sequence: sequence + uint32(len(pages))}
+				if offset > 0 {
+					page.flags = oggFlagContinued
+				}
+			}
+
+			lacing := min(oggMaxLacing, len(packet)-offset)
+			page.segments = append(page.segments, byte(lacing))
+			page.data = append(page.data, packet[offset:offset+lacing]...)
+			offset += lacing
+			if lacing < oggMaxLacing {
+				page.granule = 0 // header packets carry no samples
+				break
+			}
+		}
+	}
+	return append(pages, page)
+}
+
+func (tag *Tag) readOgg(reader *bufio.Reader) error {
+	var (
+		stream = &ogg{}
+		packet []byte
+	)
+	for stream.pages == 0 || len(stream.packets) < stream.codec.headers {
+		page, err := readOggPage(reader)
+		if err != nil {
+			return err
+		}
+		if stream.pages == 0 {
+			stream.serial = page.serial
+		} else if page.serial != stream.serial {
+			return errors.New("multiplexed ogg streams are not supported")
+		}
+		stream.pages++
+		stream.size += page.size()
+
+		offset := 0
+		for _, lacing := range page.segments {
+			packet = append(packet, page.data[offset:offset+int(lacing)]...)
+			offset += int(lacing)
+			if lacing < oggMaxLacing {
+				stream.packets = append(stream.packets, packet)
+				packet = nil
+			}
+		}
+
+		if stream.pages == 1 {
+			if stream.codec, err = oggIdentify(stream.packets); err != nil {
+				return err
+			}
+		}
+	}
+
+	// headers end on a page boundary, right before the audio ones
+	if len(packet) > 0 || len(stream.packets) != stream.codec.headers ||
+		!bytes.HasPrefix(stream.packets[1], []byte(stream.codec.comment)) {
+		return errOgg
+	}
+
+	vendor, fields, err := decodeComment(stream.packets[1][len(stream.codec.comment):])
+	if err != nil {
+		return err
+	}
+	tag.vendor = vendor
+	for _, field := range fields {
+		if key, value, _ := strings.Cut(field, "="); strings.EqualFold(key, fieldPicture) {
+			if picture, err := base64.StdEncoding.DecodeString(value); err == nil {
+				tag.pictures = append(tag.pictures, picture)
+				continue
+			}
+		}
+		tag.fields = append(tag.fields, field)
+	}
+	tag.stream = stream
+	return nil
+}
+
+func oggIdentify(packets [][]byte) (oggCodec, error) {
+	if len(packets) == 0 {
+		return oggCodec{}, errOgg
+	}
+	for _, codec := range oggCodecs {
+		if bytes.HasPrefix(packets[0], []byte(codec.identification)) {
+			return codec, nil
+		}
+	}
+	return oggCodec{}, errors.New("unsupported ogg codec")
+}
+
+func (stream *ogg) encode(tag *Tag, data []byte) ([]byte, error) {
+	if len(data) < stream.size || string(data[:len(magicOgg)]) != magicOgg {
+		return nil, errOgg
+	}
+
+	fields := tag.fields[:len(tag.fields):len(tag.fields)]
+	for _, picture := range tag.pictures {
+		fields = append(fields, fieldPicture+"="+base64.StdEncoding.EncodeToString(picture))
+	}
+	comment := append([]byte(stream.codec.comment), encodeComment(tag.vendor, fields)...)
+	if stream.codec.framing {
+		comment = append(comment, 1)
+	}
+
+	// the identification header sits alone on the first page
+	pages := oggPaginate(stream.serial, 0, stream.packets[:1])
+	pages[0].flags = oggFlagFirst
+	pages = append(pages, oggPaginate(stream.serial, 1, append([][]byte{comment}, stream.packets[2:]...))...)
+
+	var buffer bytes.Buffer
+	for _, page := range pages {
+		buffer.Write(page.encode())
+	}
+
+	// audio pages are renumbered, as header ones may have changed in count
+	var (
+		delta  = uint32(len(pages) - stream.pages)
+		reader = bytes.NewReader(data[stream.size:])
+	)
+	for reader.Len() > 0 {
+		page, err := readOggPage(reader)
+		if err != nil {
+			return nil, err
+		}
+		if page.serial == stream.serial {
+			page.sequence += delta
+		}
+		buffer.Write(page.encode())
+	}
+	return buffer.Bytes(), nil
+}
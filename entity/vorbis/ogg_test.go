@@ -0,0 +1,229 @@
+package vorbis
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/streambinder/spotitube/sys"
+	"github.com/stretchr/testify/assert"
+)
+
+const testOggSerial = 0x0badcafe
+
+func BenchmarkOgg(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		TestOpenOpus(&testing.T{})
+	}
+}
+
+func bufioReader(data []byte) *bufio.Reader {
+	return bufio.NewReader(bytes.NewReader(data))
+}
+
+func testOggPages(pages ...*oggPage) []byte {
+	var buffer bytes.Buffer
+	for _, page := range pages {
+		buffer.Write(page.encode())
+	}
+	return buffer.Bytes()
+}
+
+// header pages, as laid out by encoders, followed by two audio pages
+func testOggData(codec oggCodec, fields ...string) []byte {
+	comment := append([]byte(codec.comment), encodeComment("vendor", fields)...)
+	if codec.framing {
+		comment = append(comment, 1)
+	}
+	packets := [][]byte{comment}
+	if codec.headers > 2 {
+		packets = append(packets, []byte("\x05vorbis-setup"))
+	}
+
+	pages := oggPaginate(testOggSerial, 0, [][]byte{[]byte(codec.identification + "-identification")})
+	pages[0].flags = oggFlagFirst
+	pages = append(pages, oggPaginate(testOggSerial, 1, packets)...)
+	audio := oggPaginate(testOggSerial, uint32(len(pages)), [][]byte{[]byte("audio"), []byte("more audio")})
+	audio[0].granule = 960
+	pages = append(pages, audio...)
+	pages = append(pages, &oggPage{flags: 0x04, granule: 1920, serial: testOggSerial, sequence: uint32(len(pages)), segments: []byte{3}, data: []byte("end")})
+	return testOggPages(pages...)
+}
+
+func testOgg(t *testing.T, codec oggCodec, fields ...string) string {
+	path := filepath.Join(t.TempDir(), "track.ogg")
+	assert.Nil(t, os.WriteFile(path, testOggData(codec, fields...), 0o600))
+	return path
+}
+
+// reads back every page, checking their checksum and sequence
+func testOggRead(t *testing.T, path string) []*oggPage {
+	data, err := os.ReadFile(path)
+	assert.Nil(t, err)
+
+	var (
+		reader = bytes.NewReader(data)
+		pages  []*oggPage
+	)
+	for reader.Len() > 0 {
+		offset := len(data) - reader.Len()
+		page, err := readOggPage(reader)
+		assert.Nil(t, err)
+		assert.Equal(t, data[offset:offset+page.size()], page.encode())
+		assert.Equal(t, uint32(len(pages)), page.sequence)
+		pages = append(pages, page)
+	}
+	return pages
+}
+
+func TestOggChecksum(t *testing.T) {
+	assert.Equal(t, uint32(0x89a1897f), oggChecksum([]byte("123456789")))
+}
+
+func TestOpenOpus(t *testing.T) {
+	// testing
+	path := testOgg(t, oggCodecs[0], "TITLE=Previous", "METADATA_BLOCK_PICTURE=!", "encoder=kept")
+	tag, err := Open(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "Previous", tag.Title())
+	assert.Equal(t, []string{"TITLE=Previous", "METADATA_BLOCK_PICTURE=!", "encoder=kept"}, tag.fields)
+
+	tag.SetSpotifyID("Spotify ID")
+	tag.SetAttachedPicture([]byte("picture"))
+	assert.Nil(t, tag.Save())
+
+	tag, err = Open(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "Spotify ID", tag.SpotifyID())
+	assert.Equal(t, "kept", tag.get("ENCODER"))
+	mimeType, picture := tag.AttachedPicture()
+	assert.Equal(t, "image/jpeg", mimeType)
+	assert.Equal(t, []byte("picture"), picture)
+
+	pages := testOggRead(t, path)
+	assert.Len(t, pages, 4)
+	assert.Equal(t, byte(oggFlagFirst), pages[0].flags)
+	assert.Equal(t, uint64(0), pages[1].granule)
+	assert.Equal(t, uint64(960), pages[2].granule)
+	assert.Equal(t, []byte("end"), pages[3].data)
+}
+
+func TestOpenVorbis(t *testing.T) {
+	// testing
+	path := testOgg(t, oggCodecs[1], "TITLE=Previous")
+	tag, err := Open(path)
+	assert.Nil(t, err)
+	tag.SetTitle("Title")
+	assert.Nil(t, tag.Save())
+
+	tag, err = Open(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "Title", tag.Title())
+	stream := tag.stream.(*ogg)
+	assert.Equal(t, byte(1), stream.packets[1][len(stream.packets[1])-1])
+	assert.Equal(t, []byte("\x05vorbis-setup"), stream.packets[2])
+	assert.Len(t, testOggRead(t, path), 4)
+}
+
+func TestOpenOggGrowing(t *testing.T) {
+	// testing
+	path := testOgg(t, oggCodecs[0])
+	tag, err := Open(path)
+	assert.Nil(t, err)
+	tag.SetAttachedPicture(bytes.Repeat([]byte{0xff}, 100000))
+	assert.Nil(t, tag.Save())
+
+	pages := testOggRead(t, path)
+	assert.Len(t, pages, 6)
+	assert.Equal(t, oggGranuleNone, pages[1].granule)
+	assert.Equal(t, byte(0), pages[1].flags)
+	assert.Equal(t, byte(oggFlagContinued), pages[2].flags)
+	assert.Equal(t, byte(oggFlagContinued), pages[3].flags)
+	assert.Equal(t, uint64(0), pages[3].granule)
+	assert.Equal(t, []byte("end"), pages[5].data)
+
+	tag, err = Open(path)
+	assert.Nil(t, err)
+	tag.SetAttachedPicture(nil)
+	assert.Nil(t, tag.Save())
+	assert.Len(t, testOggRead(t, path), 4)
+}
+
+func TestOggPaginate(t *testing.T) {
+	pages := oggPaginate(testOggSerial, 1, [][]byte{make([]byte, 255), {}})
+	assert.Len(t, pages, 1)
+	assert.Equal(t, []byte{255, 0, 0}, pages[0].segments)
+
+	pages = oggPaginate(testOggSerial, 1, [][]byte{make([]byte, 255*255)})
+	assert.Len(t, pages, 2)
+	assert.Equal(t, oggGranuleNone, pages[0].granule)
+	assert.Equal(t, byte(oggFlagContinued), pages[1].flags)
+	assert.Equal(t, []byte{0}, pages[1].segments)
+	assert.Equal(t, uint32(2), pages[1].sequence)
+}
+
+func TestReadOggPageFailure(t *testing.T) {
+	page := (&oggPage{segments: []byte{3}, data: []byte("abc")}).encode()
+	for _, data := range [][]byte{
+		page[:10],
+		append([]byte("Ogg!"), page[4:]...),
+		page[:oggHeaderSize],
+		page[:len(page)-1],
+	} {
+		assert.Equal(t, errOgg, sys.ErrOnly(readOggPage(bytes.NewReader(data))))
+	}
+}
+
+func TestReadOggFailure(t *testing.T) {
+	var (
+		opus     = oggCodecs[0]
+		data     = testOggData(opus)
+		first    = (&oggPage{flags: oggFlagFirst, serial: testOggSerial, segments: []byte{8}, data: []byte("OpusHead")}).encode()
+		tags     = append([]byte(opus.comment), encodeComment("vendor", nil)...)
+		unending = &oggPage{serial: testOggSerial, segments: []byte{255}, data: make([]byte, 255)}
+	)
+	for expected, data := range map[string][]byte{
+		"corrupted ogg stream":                      data[:len(first)+10],
+		"multiplexed ogg streams are not supported": testOggPages(&oggPage{serial: testOggSerial, segments: []byte{8}, data: []byte("OpusHead")}, &oggPage{serial: 1}),
+		"unsupported ogg codec":                     testOggPages(&oggPage{serial: testOggSerial, segments: []byte{4}, data: []byte("Head")}),
+		"corrupted vorbis comment":                  testOggPages(&oggPage{serial: testOggSerial, segments: []byte{8}, data: []byte("OpusHead")}, &oggPage{serial: testOggSerial, segments: []byte{9}, data: []byte("OpusTags!")}),
+	} {
+		assert.EqualError(t, (&Tag{}).readOgg(bufioReader(data)), expected)
+	}
+	for _, pages := range [][]*oggPage{
+		{unending},
+		{oggPaginate(testOggSerial, 0, [][]byte{[]byte("OpusHead")})[0], oggPaginate(testOggSerial, 1, [][]byte{tags, []byte("audio")})[0]},
+		{oggPaginate(testOggSerial, 0, [][]byte{[]byte("OpusHead")})[0], oggPaginate(testOggSerial, 1, [][]byte{[]byte("OpusTag")})[0]},
+		{oggPaginate(testOggSerial, 0, [][]byte{[]byte("OpusHead")})[0], {serial: testOggSerial, segments: []byte{byte(len(tags)), 255}, data: append(tags, make([]byte, 255)...)}},
+	} {
+		assert.Equal(t, errOgg, (&Tag{}).readOgg(bufioReader(testOggPages(pages...))))
+	}
+}
+
+func TestEncodeOggFailure(t *testing.T) {
+	var (
+		data   = testOggData(oggCodecs[0])
+		tag    = &Tag{}
+		reader = bufioReader(data)
+	)
+	assert.Nil(t, tag.readOgg(reader))
+	assert.Equal(t, errOgg, sys.ErrOnly(tag.stream.encode(tag, data[:10])))
+	assert.Equal(t, errOgg, sys.ErrOnly(tag.stream.encode(tag, []byte(strings.Repeat("!", len(data))))))
+	assert.Equal(t, errOgg, sys.ErrOnly(tag.stream.encode(tag, append(data, "trailing"...))))
+}
+
+func TestEncodeOggForeignPages(t *testing.T) {
+	var (
+		data    = testOggData(oggCodecs[0])
+		tag     = &Tag{}
+		foreign = &oggPage{serial: 1, sequence: 42}
+	)
+	assert.Nil(t, tag.readOgg(bufioReader(data)))
+	tag.SetAttachedPicture(bytes.Repeat([]byte{0xff}, 100000))
+	encoded, err := tag.stream.encode(tag, append(data, foreign.encode()...))
+	assert.Nil(t, err)
+	assert.True(t, bytes.HasSuffix(encoded, foreign.encode()))
+}
@@ -0,0 +1,324 @@
+package vorbis
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	_ "image/jpeg" // artwork dimensions
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/streambinder/spotitube/lyrics"
+	"github.com/streambinder/spotitube/sys"
+)
+
+const (
+	fieldTitle       = "TITLE"
+	fieldArtist      = "ARTIST"
+	fieldAlbum       = "ALBUM"
+	fieldYear        = "DATE"
+	fieldTrackNumber = "TRACKNUMBER"
+	fieldLyrics      = "LYRICS"
+	fieldPicture     = "METADATA_BLOCK_PICTURE"
+	fieldSpotifyID   = "SPOTIFY_ID"
+	fieldArtworkURL  = "ARTWORK_URL"
+	fieldDuration    = "DURATION"
+	fieldUpstreamURL = "UPSTREAM_URL"
+
+	pictureFrontCover = 3
+)
+
+var (
+	errUnsupported = errors.New("unsupported container")
+	errComment     = errors.New("corrupted vorbis comment")
+	errPicture     = errors.New("corrupted picture block")
+)
+
+// the container the comments are serialized into
+type stream interface {
+	encode(tag *Tag, data []byte) ([]byte, error)
+}
+
+type Tag struct {
+	path     string
+	vendor   string
+	fields   []string // as KEY=value
+	pictures [][]byte // as FLAC picture blocks
+	stream   stream
+}
+
+// opens FLAC and Ogg (Opus or Vorbis) files,
+// telling them apart through their magic bytes
+func Open(path string) (*Tag, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var (
+		reader = bufio.NewReader(file)
+		tag    = &Tag{path: path}
+	)
+	magic, err := reader.Peek(4)
+	if err != nil {
+		return nil, err
+	}
+	switch string(magic) {
+	case magicFLAC:
+		err = tag.readFLAC(reader)
+	case magicOgg:
+		err = tag.readOgg(reader)
+	default:
+		err = errUnsupported
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+func (tag *Tag) set(field, value string) {
+	fields := tag.fields[:0:0]
+	for _, entry := range tag.fields {
+		if key, _, _ := strings.Cut(entry, "="); !strings.EqualFold(key, field) {
+			fields = append(fields, entry)
+		}
+	}
+	// empty values drop the field altogether
+	if len(value) > 0 {
+		fields = append(fields, field+"="+value)
+	}
+	tag.fields = fields
+}
+
+func (tag *Tag) get(field string) string {
+	for _, entry := range tag.fields {
+		if key, value, _ := strings.Cut(entry, "="); strings.EqualFold(key, field) {
+			return value
+		}
+	}
+	return ""
+}
+
+func (tag *Tag) SetTitle(title string) {
+	tag.set(fieldTitle, title)
+}
+
+func (tag *Tag) Title() string {
+	return tag.get(fieldTitle)
+}
+
+func (tag *Tag) SetArtist(artist string) {
+	tag.set(fieldArtist, artist)
+}
+
+func (tag *Tag) Artist() string {
+	return tag.get(fieldArtist)
+}
+
+func (tag *Tag) SetAlbum(album string) {
+	tag.set(fieldAlbum, album)
+}
+
+func (tag *Tag) Album() string {
+	return tag.get(fieldAlbum)
+}
+
+func (tag *Tag) SetYear(year string) {
+	tag.set(fieldYear, year)
+}
+
+func (tag *Tag) Year() string {
+	return tag.get(fieldYear)
+}
+
+func (tag *Tag) SetTrackNumber(number string) {
+	tag.set(fieldTrackNumber, number)
+}
+
+func (tag *Tag) TrackNumber() string {
+	return tag.get(fieldTrackNumber)
+}
+
+func (tag *Tag) SetSpotifyID(id string) {
+	tag.set(fieldSpotifyID, id)
+}
+
+func (tag *Tag) SpotifyID() string {
+	return tag.get(fieldSpotifyID)
+}
+
+func (tag *Tag) SetArtworkURL(url string) {
+	tag.set(fieldArtworkURL, url)
+}
+
+func (tag *Tag) ArtworkURL() string {
+	return tag.get(fieldArtworkURL)
+}
+
+func (tag *Tag) SetDuration(duration string) {
+	tag.set(fieldDuration, duration)
+}
+
+func (tag *Tag) Duration() string {
+	return tag.get(fieldDuration)
+}
+
+func (tag *Tag) SetUpstreamURL(url string) {
+	tag.set(fieldUpstreamURL, url)
+}
+
+func (tag *Tag) UpstreamURL() string {
+	return tag.get(fieldUpstreamURL)
+}
+
+// synced lyrics are kept in LRC format, having no dedicated field
+func (tag *Tag) SetLyrics(_, data string) {
+	tag.set(fieldLyrics, data)
+}
+
+func (tag *Tag) UnsynchronizedLyrics() string {
+	return tag.get(fieldLyrics)
+}
+
+func (tag *Tag) SynchronizedLyrics() []lyrics.SyncedLine {
+	return lyrics.GetSync(tag.UnsynchronizedLyrics())
+}
+
+func (tag *Tag) SetAttachedPicture(picture []byte) {
+	tag.pictures = nil
+	if len(picture) > 0 {
+		tag.pictures = [][]byte{encodePicture("image/jpeg", picture)}
+	}
+}
+
+func (tag *Tag) AttachedPicture() (string, []byte) {
+	for _, block := range tag.pictures {
+		if mimeType, picture, err := decodePicture(block); err == nil {
+			return mimeType, picture
+		}
+	}
+	return "", []byte{}
+}
+
+// rewrites the whole file, as comments sit before the audio stream
+func (tag *Tag) Save() error {
+	data, err := os.ReadFile(tag.path)
+	if err != nil {
+		return err
+	}
+
+	encoded, err := tag.stream.encode(tag, data)
+	if err != nil {
+		return err
+	}
+
+	temp := sys.FileBaseStem(tag.path) + ".tag" + filepath.Ext(tag.path)
+	if err := os.WriteFile(temp, encoded, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(temp, tag.path)
+}
+
+// comments are laid out the same way in every container:
+// little-endian length-prefixed vendor string and fields
+func decodeComment(data []byte) (string, []string, error) {
+	reader := bytes.NewReader(data)
+	vendor, err := readString(reader, binary.LittleEndian)
+	if err != nil {
+		return "", nil, errComment
+	}
+
+	var count uint32
+	if err := binary.Read(reader, binary.LittleEndian, &count); err != nil {
+		return "", nil, errComment
+	}
+	fields := make([]string, 0, min(int(count), reader.Len()/4))
+	for range count {
+		field, err := readString(reader, binary.LittleEndian)
+		if err != nil {
+			return "", nil, errComment
+		}
+		fields = append(fields, field)
+	}
+	return vendor, fields, nil
+}
+
+func encodeComment(vendor string, fields []string) []byte {
+	var buffer bytes.Buffer
+	writeString(&buffer, binary.LittleEndian, vendor)
+	sys.ErrSuppress(binary.Write(&buffer, binary.LittleEndian, uint32(len(fields))))
+	for _, field := range fields {
+		writeString(&buffer, binary.LittleEndian, field)
+	}
+	return buffer.Bytes()
+}
+
+// pictures follow the FLAC picture block layout, which
+// Ogg streams carry base64-encoded as METADATA_BLOCK_PICTURE
+func encodePicture(mimeType string, picture []byte) []byte {
+	var buffer bytes.Buffer
+	// undecodable pictures get their dimensions zeroed
+	config, _, _ := image.DecodeConfig(bytes.NewReader(picture))
+	sys.ErrSuppress(binary.Write(&buffer, binary.BigEndian, uint32(pictureFrontCover)))
+	writeString(&buffer, binary.BigEndian, mimeType)
+	writeString(&buffer, binary.BigEndian, "Front cover")
+	sys.ErrSuppress(binary.Write(&buffer, binary.BigEndian, []uint32{
+		uint32(config.Width), uint32(config.Height), 24, 0,
+	}))
+	writeString(&buffer, binary.BigEndian, string(picture))
+	return buffer.Bytes()
+}
+
+func decodePicture(block []byte) (string, []byte, error) {
+	var (
+		reader      = bytes.NewReader(block)
+		pictureType uint32
+		properties  [4]uint32 // width, height, depth and colors
+	)
+	if err := binary.Read(reader, binary.BigEndian, &pictureType); err != nil {
+		return "", nil, errPicture
+	}
+	mimeType, err := readString(reader, binary.BigEndian)
+	if err != nil {
+		return "", nil, errPicture
+	}
+	if _, err := readString(reader, binary.BigEndian); err != nil {
+		return "", nil, errPicture
+	}
+	if err := binary.Read(reader, binary.BigEndian, &properties); err != nil {
+		return "", nil, errPicture
+	}
+	picture, err := readString(reader, binary.BigEndian)
+	if err != nil {
+		return "", nil, errPicture
+	}
+	return mimeType, []byte(picture), nil
+}
+
+func readString(reader *bytes.Reader, order binary.ByteOrder) (string, error) {
+	var length uint32
+	if err := binary.Read(reader, order, &length); err != nil {
+		return "", err
+	}
+	if int64(length) > int64(reader.Len()) {
+		return "", io.ErrUnexpectedEOF
+	}
+	data := make([]byte, length)
+	return string(data), sys.ErrOnly(io.ReadFull(reader, data))
+}
+
+func writeString(buffer *bytes.Buffer, order binary.ByteOrder, value string) {
+	sys.ErrSuppress(binary.Write(buffer, order, uint32(len(value))))
+	buffer.WriteString(value)
+}
+
+// Open closes the file as soon as the comments are parsed
+func (tag *Tag) Close() error {
+	return nil
+}
@@ -0,0 +1,167 @@
+package vorbis
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bytedance/mockey"
+	"github.com/streambinder/spotitube/lyrics"
+	"github.com/streambinder/spotitube/sys"
+	"github.com/stretchr/testify/assert"
+)
+
+func BenchmarkVorbis(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		TestOpenFLAC(&testing.T{})
+	}
+}
+
+func testPicture(t *testing.T) []byte {
+	var buffer bytes.Buffer
+	assert.Nil(t, jpeg.Encode(&buffer, image.NewRGBA(image.Rect(0, 0, 2, 3)), nil))
+	return buffer.Bytes()
+}
+
+func TestOpenFLAC(t *testing.T) {
+	// testing
+	path := testFLAC(t, "TITLE=Previous", "comment=kept")
+	tag, err := Open(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "Previous", tag.Title())
+
+	picture := testPicture(t)
+	tag.SetTitle("Title")
+	tag.SetArtist("Artist")
+	tag.SetAlbum("Album")
+	tag.SetYear("1970")
+	tag.SetTrackNumber("1")
+	tag.SetSpotifyID("Spotify ID")
+	tag.SetArtworkURL("Artwork URL")
+	tag.SetDuration("60")
+	tag.SetUpstreamURL("Upstream URL")
+	tag.SetLyrics("Title", "[00:27.37]lyrics")
+	tag.SetAttachedPicture(picture)
+	assert.Nil(t, tag.Save())
+
+	tag, err = Open(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "vendor", tag.vendor)
+	assert.Equal(t, "Title", tag.Title())
+	assert.Equal(t, "Artist", tag.Artist())
+	assert.Equal(t, "Album", tag.Album())
+	assert.Equal(t, "1970", tag.Year())
+	assert.Equal(t, "1", tag.TrackNumber())
+	assert.Equal(t, "Spotify ID", tag.SpotifyID())
+	assert.Equal(t, "Artwork URL", tag.ArtworkURL())
+	assert.Equal(t, "60", tag.Duration())
+	assert.Equal(t, "Upstream URL", tag.UpstreamURL())
+	assert.Equal(t, "[00:27.37]lyrics", tag.UnsynchronizedLyrics())
+	assert.Equal(t, []lyrics.SyncedLine{{Time: 27370, Text: "lyrics"}}, tag.SynchronizedLyrics())
+	assert.Equal(t, "kept", tag.get("COMMENT"))
+	mimeType, data := tag.AttachedPicture()
+	assert.Equal(t, "image/jpeg", mimeType)
+	assert.Equal(t, picture, data)
+	assert.Equal(t, uint32(2), binary.BigEndian.Uint32(tag.pictures[0][len(tag.pictures[0])-len(picture)-20:]))
+
+	content, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, byte(0), content[4]) // stream info comes first
+	assert.True(t, bytes.HasSuffix(content, []byte("frames")))
+	assert.NoFileExists(t, filepath.Join(filepath.Dir(path), "track.tag.flac"))
+	assert.Nil(t, tag.Close())
+}
+
+func TestOpenFailure(t *testing.T) {
+	assert.Error(t, sys.ErrOnly(Open(filepath.Join(t.TempDir(), "missing.flac"))))
+}
+
+func TestOpenShort(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "track.flac")
+	assert.Nil(t, os.WriteFile(path, []byte("fL"), 0o600))
+	assert.Error(t, sys.ErrOnly(Open(path)))
+}
+
+func TestOpenUnsupported(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "track.mp3")
+	assert.Nil(t, os.WriteFile(path, []byte("ID3\x04"), 0o600))
+	assert.EqualError(t, sys.ErrOnly(Open(path)), "unsupported container")
+}
+
+func TestOpenCorrupted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "track.flac")
+	assert.Nil(t, os.WriteFile(path, []byte("fLaC\x00"), 0o600))
+	assert.EqualError(t, sys.ErrOnly(Open(path)), "corrupted flac metadata")
+}
+
+func TestSetEmpty(t *testing.T) {
+	tag := &Tag{fields: []string{"TITLE=Title", "title=Other", "ARTIST=Artist"}}
+	tag.SetTitle("")
+	assert.Equal(t, []string{"ARTIST=Artist"}, tag.fields)
+	assert.Empty(t, tag.Title())
+	tag.SetAttachedPicture(nil)
+	assert.Nil(t, tag.pictures)
+}
+
+func TestAttachedPictureCorrupted(t *testing.T) {
+	tag := &Tag{pictures: [][]byte{{0}}}
+	mimeType, picture := tag.AttachedPicture()
+	assert.Empty(t, mimeType)
+	assert.Empty(t, picture)
+}
+
+func TestSaveReadFailure(t *testing.T) {
+	path := testFLAC(t)
+	tag, err := Open(path)
+	assert.Nil(t, err)
+	assert.Nil(t, os.Remove(path))
+	assert.Error(t, tag.Save())
+}
+
+func TestSaveEncodeFailure(t *testing.T) {
+	path := testFLAC(t)
+	tag, err := Open(path)
+	assert.Nil(t, err)
+	assert.Nil(t, os.WriteFile(path, []byte("fLaC"), 0o600))
+	assert.EqualError(t, tag.Save(), "corrupted flac metadata")
+}
+
+func TestSaveWriteFailure(t *testing.T) {
+	path := testFLAC(t)
+	tag, err := Open(path)
+	assert.Nil(t, err)
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(os.WriteFile).Return(errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, tag.Save(), "ko")
+}
+
+func TestDecodeCommentFailure(t *testing.T) {
+	for _, data := range [][]byte{
+		{},
+		encodeComment("vendor", nil)[:10],
+		encodeComment("vendor", []string{"TITLE=Title"})[:20],
+	} {
+		assert.Equal(t, errComment, sys.ErrOnly(decodeComment(data)))
+	}
+}
+
+func TestDecodePictureFailure(t *testing.T) {
+	block := encodePicture("image/jpeg", []byte("picture"))
+	for _, size := range []int{0, 4, 8, 14, 19, 30, 38, 45, 49, 55} {
+		_, _, err := decodePicture(block[:size])
+		assert.Equal(t, errPicture, err, size)
+	}
+	mimeType, picture, err := decodePicture(block)
+	assert.Nil(t, err)
+	assert.Equal(t, "image/jpeg", mimeType)
+	assert.Equal(t, []byte("picture"), picture)
+}
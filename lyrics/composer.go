@@ -1,6 +1,7 @@
 package lyrics
 
 import (
+	"bytes"
 	"context"
 	"os"
 	"path/filepath"
@@ -13,6 +14,10 @@ import (
 	"github.com/streambinder/spotitube/sys"
 )
 
+// lyrics served from the local cache cannot
+// be traced back to the composer they came from
+const SourceCache = "cache"
+
 var (
 	composers    = []Composer{}
 	reSyncedLine = regexp.MustCompile(`^\[(\d{2}:\d{2}\.\d{2})\]\s*(.+)`)
@@ -26,6 +31,7 @@ type SyncedLine struct {
 type Composer interface {
 	search(*entity.Track, ...context.Context) ([]byte, error)
 	get(string, ...context.Context) ([]byte, error)
+	String() string
 }
 
 func IsSynced(data interface{}) bool {
@@ -41,25 +47,20 @@ func IsSynced(data interface{}) bool {
 	return reSyncedLine.MatchString(strings.Split(lyrics, "\n")[0])
 }
 
+// strips the timestamps out of LRC lyrics,
+// leaving plain ones untouched
 func GetPlain(lyrics string) string {
-	// In an ideal world, standards are standards and everyone follows them.
-	// Unfortunately, the world is not ideal, hence the USLT and SYLT ID3 tags
-	// aren't the standard way of distinguishing between plain and synced lyrics.
-	// Rather, it's enough for the USLT tag to be in LRC format to be considered
-	// synced. I'll leave the ideal logic commented out for future reference.
-
-	// if !IsSynced(lyrics) {
-	// 	return lyrics
-	// }
-
-	// lines := strings.Split(lyrics, "\n")
-	// for i, line := range lines {
-	// 	if matches := reSyncedLine.FindStringSubmatch(line); len(matches) == 3 {
-	// 		lines[i] = matches[2]
-	// 	}
-	// }
-	// return strings.Join(lines, "\n")
-	return lyrics
+	if !IsSynced(lyrics) {
+		return lyrics
+	}
+
+	lines := strings.Split(lyrics, "\n")
+	for i, line := range lines {
+		if matches := reSyncedLine.FindStringSubmatch(line); len(matches) == 3 {
+			lines[i] = matches[2]
+		}
+	}
+	return strings.Join(lines, "\n")
 }
 
 func GetSync(lyrics string) []SyncedLine {
@@ -105,13 +106,21 @@ func chooseComposition(first, latter []byte) []byte {
 
 // not found entries return no error
 func Search(track *entity.Track) (string, error) {
+	lyrics, _, err := SearchWithSource(track)
+	return lyrics, err
+}
+
+// same as Search, but also returns the name of
+// the composer the chosen lyrics come from
+func SearchWithSource(track *entity.Track) (string, string, error) {
 	if bytes, err := os.ReadFile(track.Path().Lyrics()); err == nil {
-		return string(bytes), nil
+		return string(bytes), SourceCache, nil
 	}
 
 	var (
 		workers        []nursery.ConcurrentJob
 		result         []byte
+		source         string
 		mu             sync.Mutex
 		ctxBackground  = context.Background()
 		ctx, ctxCancel = context.WithCancel(ctxBackground)
@@ -129,6 +138,9 @@ func Search(track *entity.Track) (string, error) {
 
 				mu.Lock()
 				if choice := chooseComposition(lyrics, result); choice != nil {
+					if !bytes.Equal(choice, result) {
+						source = c.String()
+					}
 					result = choice
 					if IsSynced(choice) {
 						ctxCancel()
@@ -140,18 +152,18 @@ func Search(track *entity.Track) (string, error) {
 	}
 
 	if err := nursery.RunConcurrentlyWithContext(ctx, workers...); err != nil {
-		return "", err
+		return "", "", err
 	}
 
 	if len(result) == 0 {
-		return "", nil
+		return "", "", nil
 	}
 
 	if err := os.MkdirAll(filepath.Dir(track.Path().Lyrics()), 0o755); err != nil {
-		return "", err
+		return "", "", err
 	}
 
-	return string(result), os.WriteFile(track.Path().Lyrics(), result, 0o600)
+	return string(result), source, os.WriteFile(track.Path().Lyrics(), result, 0o600)
 }
 
 func Get(url string) (string, error) {
@@ -32,7 +32,7 @@ func TestIsSynced(t *testing.T) {
 }
 
 func TestGetPlain(t *testing.T) {
-	assert.Equal(t, GetPlain("[00:27.37]lyrics"), "[00:27.37]lyrics")
+	assert.Equal(t, GetPlain("[00:27.37]lyrics\n[00:29.01] more lyrics"), "lyrics\nmore lyrics")
 	assert.Equal(t, GetPlain("lyrics"), "lyrics")
 }
 
@@ -66,9 +66,10 @@ func TestSearch(t *testing.T) {
 		return []byte("[00:27.37]llyrics"), nil
 	}).Build()
 	// testing
-	lyrics, err := Search(track)
+	lyrics, source, err := SearchWithSource(track)
 	assert.Nil(t, err)
 	assert.Equal(t, "[00:27.37]llyrics", lyrics)
+	assert.Equal(t, "lrclib", source)
 }
 
 func TestSearchAlreadyExists(t *testing.T) {
@@ -80,6 +81,9 @@ func TestSearchAlreadyExists(t *testing.T) {
 	lyrics, err := Search(track)
 	assert.Nil(t, err)
 	assert.Equal(t, "lyrics", lyrics)
+	_, source, err := SearchWithSource(track)
+	assert.Nil(t, err)
+	assert.Equal(t, SourceCache, source)
 }
 
 func TestSearchFailure(t *testing.T) {
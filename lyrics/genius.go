@@ -48,6 +48,10 @@ func init() {
 	composers = append(composers, &genius{})
 }
 
+func (genius) String() string {
+	return "genius"
+}
+
 func (composer genius) search(track *entity.Track, ctxs ...context.Context) ([]byte, error) {
 	var (
 		ctx            = context.Background()
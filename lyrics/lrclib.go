@@ -27,6 +27,10 @@ func init() {
 	composers = append(composers, &lrclib{})
 }
 
+func (lrclib) String() string {
+	return "lrclib"
+}
+
 func (composer lrclib) search(track *entity.Track, ctxs ...context.Context) ([]byte, error) {
 	ctx := context.Background()
 	if len(ctxs) > 0 {
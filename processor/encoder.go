@@ -4,9 +4,8 @@ import (
 	"errors"
 	"strconv"
 
-	"github.com/bogem/id3v2/v2"
 	"github.com/streambinder/spotitube/entity"
-	"github.com/streambinder/spotitube/entity/id3"
+	"github.com/streambinder/spotitube/entity/tags"
 )
 
 type encoder struct{}
@@ -22,7 +21,7 @@ func (encoder) Do(object interface{}) error {
 		return errors.New("processor does not support such object")
 	}
 
-	tag, err := id3.Open(track.Path().Download(), id3v2.Options{Parse: false})
+	tag, err := tags.Open(track.Path().Download(), tags.ParseNone)
 	if err != nil {
 		return err
 	}
@@ -2,10 +2,14 @@ package processor
 
 import (
 	"errors"
+	"path/filepath"
 	"testing"
 
 	"github.com/bogem/id3v2/v2"
 	"github.com/bytedance/mockey"
+	"github.com/streambinder/spotitube/entity"
+	"github.com/streambinder/spotitube/entity/vorbis"
+	"github.com/streambinder/spotitube/sys/cmd"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -48,3 +52,44 @@ func TestEncoderDoSaveFailure(t *testing.T) {
 	// testing
 	assert.EqualError(t, encoder{}.Do(track), "ko")
 }
+
+func TestEncoderDoVorbis(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	assert.Nil(t, entity.SetFormat(entity.FormatFLAC))
+	defer func() { assert.Nil(t, entity.SetFormat(entity.FormatMP3)) }()
+	mockey.Mock(vorbis.Open).To(func(path string) (*vorbis.Tag, error) {
+		assert.Equal(t, ".flac", filepath.Ext(path))
+		return &vorbis.Tag{}, nil
+	}).Build()
+	mockey.Mock(mockey.GetMethod(&vorbis.Tag{}, "Save")).Return(nil).Build()
+
+	// testing
+	assert.Nil(t, encoder{}.Do(track))
+}
+
+func TestEncoderDoVorbisOpenFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	assert.Nil(t, entity.SetFormat(entity.FormatOpus))
+	defer func() { assert.Nil(t, entity.SetFormat(entity.FormatMP3)) }()
+	mockey.Mock(vorbis.Open).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, encoder{}.Do(track), "ko")
+}
+
+func TestEncoderDoFFmeta(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	assert.Nil(t, entity.SetFormat(entity.FormatM4A))
+	defer func() { assert.Nil(t, entity.SetFormat(entity.FormatMP3)) }()
+	mockey.Mock(mockey.GetMethod(cmd.FFmpegCmd{}, "Tag")).To(func(path string, tags map[string]string, _ string) error {
+		assert.Equal(t, ".m4a", filepath.Ext(path))
+		assert.Equal(t, track.ID, tags["spotify_id"])
+		return nil
+	}).Build()
+
+	// testing
+	assert.Nil(t, encoder{}.Do(track))
+}
@@ -10,8 +10,11 @@ import (
 	"github.com/streambinder/spotitube/entity"
 )
 
+const defaultWeight = 100
+
 var (
 	providers  = []Provider{}
+	weights    = map[string]int{} // scores scaling, in percentage, by provider name
 	misleading = []string{"cover", "live", "karaoke", "performance", "studio", "instrumental", "remix", "acoustic"}
 )
 
@@ -22,6 +25,50 @@ type Match struct {
 
 type Provider interface {
 	search(track *entity.Track) ([]*Match, error)
+	String() string
+}
+
+// weights scale the scores of the matches of each provider,
+// in percentage: a zero weight disables the provider altogether
+func SetWeights(providerWeights map[string]int) error {
+	for name, weight := range providerWeights {
+		if !known(name) {
+			return errors.New("unknown provider: " + name)
+		}
+		if weight < 0 {
+			return errors.New("invalid weight for provider: " + name)
+		}
+	}
+
+	weights = make(map[string]int)
+	for name, weight := range providerWeights {
+		weights[name] = weight
+	}
+	return nil
+}
+
+func Weights() map[string]int {
+	providerWeights := make(map[string]int)
+	for _, provider := range providers {
+		providerWeights[provider.String()] = weight(provider)
+	}
+	return providerWeights
+}
+
+func known(name string) bool {
+	for _, provider := range providers {
+		if provider.String() == name {
+			return true
+		}
+	}
+	return false
+}
+
+func weight(provider Provider) int {
+	if weight, ok := weights[provider.String()]; ok {
+		return weight
+	}
+	return defaultWeight
 }
 
 func Search(track *entity.Track) ([]*Match, error) {
@@ -32,6 +79,9 @@ func Search(track *entity.Track) ([]*Match, error) {
 		errCount int
 	)
 	for _, provider := range providers {
+		if weight(provider) == 0 {
+			continue
+		}
 		workers = append(workers, func(p Provider) func(ctx context.Context, ch chan error) {
 			return func(_ context.Context, _ chan error) {
 				scopedMatches, err := p.search(track)
@@ -41,6 +91,9 @@ func Search(track *entity.Track) ([]*Match, error) {
 					mu.Unlock()
 					return
 				}
+				for _, match := range scopedMatches {
+					match.Score = match.Score * weight(p) / defaultWeight
+				}
 				mu.Lock()
 				matches = append(matches, scopedMatches...)
 				mu.Unlock()
@@ -48,6 +101,10 @@ func Search(track *entity.Track) ([]*Match, error) {
 		}(provider))
 	}
 
+	if len(workers) == 0 {
+		return nil, errors.New("no provider enabled")
+	}
+
 	if err := nursery.RunConcurrently(workers...); err != nil {
 		return nil, err
 	}
@@ -76,3 +76,39 @@ func TestSearchNurseryFailure(t *testing.T) {
 	_, err := Search(track)
 	assert.EqualError(t, err, "nursery ko")
 }
+
+func TestSearchWeights(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(youTube{}, "search")).Return([]*Match{
+		{URL: "url1", Score: 100},
+	}, nil).Build()
+	mockey.Mock(mockey.GetMethod(qobuz{}, "search")).Return([]*Match{
+		{URL: "url2", Score: 100},
+	}, nil).Build()
+	defer func() { assert.Nil(t, SetWeights(nil)) }()
+
+	// testing
+	assert.Nil(t, SetWeights(map[string]int{"youtube": 50, "qobuz": 0}))
+	assert.Equal(t, map[string]int{"youtube": 50, "qobuz": 0}, Weights())
+	matches, err := Search(track)
+	assert.Nil(t, err)
+	assert.Len(t, matches, 1)
+	assert.Equal(t, "url1", matches[0].URL)
+	assert.Equal(t, 50, matches[0].Score)
+}
+
+func TestSearchWeightsDisabled(t *testing.T) {
+	defer func() { assert.Nil(t, SetWeights(nil)) }()
+
+	// testing
+	assert.Nil(t, SetWeights(map[string]int{"youtube": 0, "qobuz": 0}))
+	_, err := Search(track)
+	assert.EqualError(t, err, "no provider enabled")
+}
+
+func TestSetWeightsFailure(t *testing.T) {
+	assert.EqualError(t, SetWeights(map[string]int{"provider": 1}), "unknown provider: provider")
+	assert.EqualError(t, SetWeights(map[string]int{"youtube": -1}), "invalid weight for provider: youtube")
+	assert.Equal(t, map[string]int{"youtube": 100, "qobuz": 100}, Weights())
+}
@@ -46,6 +46,10 @@ func init() {
 	providers = append(providers, qobuz{})
 }
 
+func (qobuz) String() string {
+	return "qobuz"
+}
+
 func (qobuz) search(track *entity.Track) ([]*Match, error) {
 	trackID, err := qobuzSearchTrack(track)
 	if err != nil || trackID == 0 {
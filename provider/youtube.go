@@ -98,6 +98,10 @@ func init() {
 	providers = append(providers, youTube{})
 }
 
+func (youTube) String() string {
+	return "youtube"
+}
+
 func sanitizeYouTubeQuery(q string) string {
 	// YouTube interprets -word as exclusion operator, filter out hyphens
 	// to avoid unintended query filtering
@@ -29,7 +29,13 @@ func (client *Client) Album(target string, channels ...chan interface{}) (*entit
 	if err != nil {
 		return nil, err
 	}
+	return client.album(fullAlbum, channels...)
+}
 
+// walks through all the tracks of an album, as
+// only the first page of them comes along with it
+func (client *Client) album(fullAlbum *spotify.FullAlbum, channels ...chan interface{}) (*entity.Album, error) {
+	ctx := context.Background()
 	album := albumEntity(fullAlbum)
 	for {
 		for _, albumTrack := range fullAlbum.Tracks.Tracks {
@@ -39,6 +39,7 @@ func TestAlbum(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, fullAlbum.ID.String(), album.ID)
 	assert.Equal(t, fullAlbum.Name, album.Name)
+	assert.Equal(t, fullAlbum.Artists[0].Name, album.Tracks[0].AlbumArtist)
 	assert.Equal(t, len(fullAlbum.Artists), len(album.Artists))
 	assert.Equal(t, len(fullAlbum.Tracks.Tracks), len(album.Tracks))
 }
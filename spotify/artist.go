@@ -0,0 +1,177 @@
+package spotify
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+
+	"github.com/zmb3/spotify/v2"
+)
+
+const tracksBatchSize = 50 // max number of tracks fetchable at once
+
+var (
+	idPattern  = regexp.MustCompile(`^[0-9A-Za-z]{22}$`)
+	albumTypes = map[string]spotify.AlbumType{
+		"album":       spotify.AlbumTypeAlbum,
+		"single":      spotify.AlbumTypeSingle,
+		"compilation": spotify.AlbumTypeCompilation,
+		"appears_on":  spotify.AlbumTypeAppearsOn,
+	}
+)
+
+// filters the releases an artist discography is made of:
+// an empty market lets Spotify pick the user's one
+type ArtistFilter struct {
+	AlbumTypes []string
+	Market     string
+}
+
+func (filter ArtistFilter) options() []spotify.RequestOption {
+	if len(filter.Market) == 0 {
+		return []spotify.RequestOption{}
+	}
+	return []spotify.RequestOption{spotify.Market(filter.Market)}
+}
+
+func (filter ArtistFilter) albumTypes() ([]spotify.AlbumType, error) {
+	var types []spotify.AlbumType
+	for _, name := range filter.AlbumTypes {
+		albumType, ok := albumTypes[name]
+		if !ok {
+			return nil, errors.New("unsupported album type: " + name)
+		}
+		types = append(types, albumType)
+	}
+	return types, nil
+}
+
+// artists can be targeted by name too,
+// resolving to the most relevant one
+func (client *Client) artistID(target string) (spotify.ID, error) {
+	if id := id(target); idPattern.MatchString(id.String()) {
+		return id, nil
+	}
+
+	search, err := client.Search(context.Background(), target, spotify.SearchTypeArtist, spotify.Limit(1))
+	if err != nil {
+		return "", err
+	}
+	if search.Artists == nil || len(search.Artists.Artists) == 0 {
+		return "", errors.New("artist not found: " + target)
+	}
+	return search.Artists.Artists[0].ID, nil
+}
+
+// the discography is walked through all the releases of the artist, while
+// the tracks appearing on several of them are only passed once, by ISRC,
+// and the ones the artist does not take part in are left out
+func (client *Client) Artist(target string, filter ArtistFilter, channels ...chan interface{}) error {
+	types, err := filter.albumTypes()
+	if err != nil {
+		return err
+	}
+
+	id, err := client.artistID(target)
+	if err != nil {
+		return err
+	}
+
+	albums, err := client.artistAlbums(id, types, filter.options())
+	if err != nil {
+		return err
+	}
+
+	var ids []spotify.ID
+	for _, album := range albums {
+		albumIDs, err := client.albumTracks(album, filter.options())
+		if err != nil {
+			return err
+		}
+		ids = append(ids, albumIDs...)
+	}
+
+	seen := make(map[string]bool)
+	for start := 0; start < len(ids); start += tracksBatchSize {
+		fullTracks, err := client.GetTracks(context.Background(), ids[start:min(start+tracksBatchSize, len(ids))], filter.options()...)
+		if err != nil {
+			return err
+		}
+
+		for _, fullTrack := range fullTracks {
+			// compilations and appearances carry other artists' tracks too
+			if fullTrack == nil || !slices.ContainsFunc(fullTrack.Artists, func(artist spotify.SimpleArtist) bool {
+				return artist.ID == id
+			}) {
+				continue
+			}
+
+			key := fullTrack.ExternalIDs["isrc"]
+			if len(key) == 0 {
+				key = fullTrack.ID.String()
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			track := trackEntity(*fullTrack)
+			for _, ch := range channels {
+				ch <- track
+			}
+		}
+	}
+
+	return nil
+}
+
+func (client *Client) artistAlbums(id spotify.ID, types []spotify.AlbumType, options []spotify.RequestOption) ([]spotify.ID, error) {
+	var (
+		ctx       = context.Background()
+		page, err = client.GetArtistAlbums(ctx, id, types, options...)
+		albums    []spotify.ID
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		for _, album := range page.Albums {
+			albums = append(albums, album.ID)
+		}
+
+		if err := client.NextPage(ctx, page); errors.Is(err, spotify.ErrNoMorePages) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	return albums, nil
+}
+
+func (client *Client) albumTracks(id spotify.ID, options []spotify.RequestOption) ([]spotify.ID, error) {
+	var (
+		ctx       = context.Background()
+		page, err = client.GetAlbumTracks(ctx, id, options...)
+		tracks    []spotify.ID
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		for _, track := range page.Tracks {
+			tracks = append(tracks, track.ID)
+		}
+
+		if err := client.NextPage(ctx, page); errors.Is(err, spotify.ErrNoMorePages) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	return tracks, nil
+}
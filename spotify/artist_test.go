@@ -0,0 +1,172 @@
+package spotify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bytedance/mockey"
+	"github.com/streambinder/spotitube/sys"
+	"github.com/stretchr/testify/assert"
+	"github.com/zmb3/spotify/v2"
+)
+
+const artistID = "0OdUWJ0sBjDrqHygGUXeCF"
+
+var artistFilter = ArtistFilter{AlbumTypes: []string{"album", "single"}, Market: "IT"}
+
+func BenchmarkArtist(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		TestArtist(&testing.T{})
+	}
+}
+
+func testArtistTracks(ids []spotify.ID) []*spotify.FullTrack {
+	var fullTracks []*spotify.FullTrack
+	for _, id := range ids {
+		fullTrack := &spotify.FullTrack{SimpleTrack: spotify.SimpleTrack{
+			ID:      id,
+			Name:    id.String(),
+			Artists: []spotify.SimpleArtist{{ID: "featuring"}, {ID: artistID}},
+		}}
+		switch id {
+		case "nil":
+			fullTrack = nil
+		case "other":
+			fullTrack.Artists = []spotify.SimpleArtist{{ID: "other"}}
+		case "isrc":
+			fullTrack.ExternalIDs = map[string]string{}
+		default:
+			fullTrack.ExternalIDs = map[string]string{"isrc": id.String()}
+		}
+		fullTracks = append(fullTracks, fullTrack)
+	}
+	return fullTracks
+}
+
+func TestArtist(t *testing.T) {
+	var batches = 0
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "GetArtistAlbums")).Return(&spotify.SimpleAlbumPage{
+		Albums: []spotify.SimpleAlbum{{ID: "album"}, {ID: "single"}},
+	}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "GetAlbumTracks")).To(func(_ *spotify.Client, _ context.Context, id spotify.ID, _ ...spotify.RequestOption) (*spotify.SimpleTrackPage, error) {
+		page := &spotify.SimpleTrackPage{Tracks: []spotify.SimpleTrack{{ID: "nil"}, {ID: "isrc"}, {ID: "other"}}}
+		for i := range 30 {
+			// the single is part of the album too
+			page.Tracks = append(page.Tracks, spotify.SimpleTrack{ID: spotify.ID(fmt.Sprintf("track%d", i))})
+		}
+		return page, nil
+	}).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "GetTracks")).To(func(_ *spotify.Client, _ context.Context, ids []spotify.ID, _ ...spotify.RequestOption) ([]*spotify.FullTrack, error) {
+		batches++
+		assert.LessOrEqual(t, len(ids), tracksBatchSize)
+		return testArtistTracks(ids), nil
+	}).Build()
+
+	// testing
+	channel := make(chan interface{}, 64)
+	defer close(channel)
+	assert.Nil(t, testClient().Artist(artistID, artistFilter, channel))
+	assert.Equal(t, 2, batches)
+	assert.Len(t, channel, 31)
+}
+
+func TestArtistByName(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Search")).Return(&spotify.SearchResult{
+		Artists: &spotify.FullArtistPage{Artists: []spotify.FullArtist{{SimpleArtist: spotify.SimpleArtist{ID: artistID}}}},
+	}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "GetArtistAlbums")).To(func(_ *spotify.Client, _ context.Context, id spotify.ID, _ []spotify.AlbumType, _ ...spotify.RequestOption) (*spotify.SimpleAlbumPage, error) {
+		assert.Equal(t, spotify.ID(artistID), id)
+		return &spotify.SimpleAlbumPage{}, nil
+	}).Build()
+
+	// testing
+	assert.Nil(t, testClient().Artist("Artist", ArtistFilter{}))
+}
+
+func TestArtistNotFound(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Search")).Return(&spotify.SearchResult{}, nil).Build()
+
+	// testing
+	assert.EqualError(t, testClient().Artist("Artist", artistFilter), "artist not found: Artist")
+}
+
+func TestArtistSearchFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Search")).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, testClient().Artist("Artist", artistFilter), "ko")
+}
+
+func TestArtistAlbumTypeFailure(t *testing.T) {
+	assert.EqualError(t, testClient().Artist(artistID, ArtistFilter{AlbumTypes: []string{"ep"}}), "unsupported album type: ep")
+}
+
+func TestArtistGetArtistAlbumsFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "GetArtistAlbums")).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, testClient().Artist(artistID, artistFilter), "ko")
+}
+
+func TestArtistGetArtistAlbumsNextPageFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "GetArtistAlbums")).Return(&spotify.SimpleAlbumPage{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "NextPage")).Return(errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, testClient().Artist(artistID, artistFilter), "ko")
+}
+
+func TestArtistGetAlbumTracksFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "GetArtistAlbums")).Return(&spotify.SimpleAlbumPage{
+		Albums: []spotify.SimpleAlbum{{ID: "album"}},
+	}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "GetAlbumTracks")).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, testClient().Artist(artistID, artistFilter), "ko")
+}
+
+func TestArtistGetAlbumTracksNextPageFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "GetArtistAlbums")).Return(&spotify.SimpleAlbumPage{
+		Albums: []spotify.SimpleAlbum{{ID: "album"}},
+	}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "GetAlbumTracks")).Return(&spotify.SimpleTrackPage{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "NextPage")).Return(mockey.Sequence(spotify.ErrNoMorePages).Then(errors.New("ko"))).Build()
+
+	// testing
+	assert.EqualError(t, testClient().Artist(artistID, artistFilter), "ko")
+}
+
+func TestArtistGetTracksFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "GetArtistAlbums")).Return(&spotify.SimpleAlbumPage{
+		Albums: []spotify.SimpleAlbum{{ID: "album"}},
+	}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "GetAlbumTracks")).Return(&spotify.SimpleTrackPage{
+		Tracks: []spotify.SimpleTrack{{ID: "track"}},
+	}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "GetTracks")).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testClient().Artist(artistID, artistFilter)), "ko")
+}
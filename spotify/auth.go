@@ -1,13 +1,19 @@
 package spotify
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/arunsworld/nursery"
@@ -27,6 +33,7 @@ const (
 
 var (
 	port               = 65535
+	redirectHost       = "127.0.0.1"
 	tokenPath          = sys.CacheFile(TokenBasename)
 	fallbackSpotifyID  = ""
 	fallbackSpotifyKey = ""
@@ -39,34 +46,34 @@ type Client struct {
 	cache         map[string]interface{}
 }
 
-func Authenticate(urlProcessor func(string) error) (*Client, error) {
-	var (
-		client    Client
-		serverMux = http.NewServeMux()
-		server    = &http.Server{
-			Addr:              fmt.Sprintf("127.0.0.1:%d", port),
-			Handler:           serverMux,
-			ReadHeaderTimeout: 2 * time.Second,
-		}
-		state         = randstr.Hex(20)
-		clientChannel = make(chan *spotify.Client, 1)
-		errChannel    = make(chan error, 1)
-	)
-	defer close(clientChannel)
-	defer close(errChannel)
+// the authorization parameters shared by the
+// callback server based and the headless flows
+type authFlow struct {
+	authenticator *spotifyauth.Authenticator
+	state         string
+	authOptions   []oauth2.AuthCodeOption
+	tokenOptions  []oauth2.AuthCodeOption
+}
+
+func newAuthFlow() (*authFlow, error) {
+	flow := &authFlow{state: randstr.Hex(20)}
 
 	clientID := sys.Fallback(os.Getenv("SPOTIFY_ID"), fallbackSpotifyID)
 	if clientID == "" {
 		return nil, errors.New("SPOTIFY_ID not set")
 	}
 
+	// without a client secret, the authorization code flow is
+	// secured with PKCE instead, so that none has to be distributed
 	clientSecret := sys.Fallback(os.Getenv("SPOTIFY_KEY"), fallbackSpotifyKey)
 	if clientSecret == "" {
-		return nil, errors.New("SPOTIFY_KEY not set")
+		verifier := oauth2.GenerateVerifier()
+		flow.authOptions = append(flow.authOptions, oauth2.S256ChallengeOption(verifier))
+		flow.tokenOptions = append(flow.tokenOptions, oauth2.VerifierOption(verifier))
 	}
 
-	authenticator := spotifyauth.New(
-		spotifyauth.WithRedirectURL(fmt.Sprintf("http://127.0.0.1:%d/callback", port)),
+	flow.authenticator = spotifyauth.New(
+		spotifyauth.WithRedirectURL(fmt.Sprintf("http://%s/callback", net.JoinHostPort(redirectHost, strconv.Itoa(port)))),
 		spotifyauth.WithScopes(
 			spotifyauth.ScopeUserLibraryRead,
 			spotifyauth.ScopeUserLibraryModify,
@@ -78,6 +85,48 @@ func Authenticate(urlProcessor func(string) error) (*Client, error) {
 		spotifyauth.WithClientID(clientID),
 		spotifyauth.WithClientSecret(clientSecret),
 	)
+	return flow, nil
+}
+
+// sessions of different profiles are kept apart, each in its own token file
+func SetProfile(profile string) {
+	tokenPath = sys.ProfileCacheFile(profile, TokenBasename)
+	cachePath = sys.ProfileCacheFile(profile, CacheBasename)
+}
+
+func TokenPath() string {
+	return tokenPath
+}
+
+// the callback server listens on the given host and port, which the
+// redirect URL registered on Spotify must point to: empty ones are kept
+func SetRedirect(host string, callbackPort int) {
+	redirectHost = sys.Fallback(host, redirectHost)
+	if callbackPort > 0 {
+		port = callbackPort
+	}
+}
+
+func Authenticate(urlProcessor func(string) error) (*Client, error) {
+	var (
+		client    Client
+		serverMux = http.NewServeMux()
+		server    = &http.Server{
+			Addr:              net.JoinHostPort(redirectHost, strconv.Itoa(port)),
+			Handler:           serverMux,
+			ReadHeaderTimeout: 2 * time.Second,
+		}
+		clientChannel = make(chan *spotify.Client, 1)
+		errChannel    = make(chan error, 1)
+	)
+	defer close(clientChannel)
+	defer close(errChannel)
+
+	flow, err := newAuthFlow()
+	if err != nil {
+		return nil, err
+	}
+	authenticator, state := flow.authenticator, flow.state
 	if client, err := Recover(authenticator, state); err == nil {
 		return client, client.Persist()
 	}
@@ -85,7 +134,7 @@ func Authenticate(urlProcessor func(string) error) (*Client, error) {
 	serverMux.HandleFunc("/callback", func(writer http.ResponseWriter, request *http.Request) {
 		request.Body = http.MaxBytesReader(writer, request.Body, 1<<20)
 		fmt.Fprintln(writer, closeTabHTML)
-		token, err := authenticator.Token(request.Context(), state, request)
+		token, err := authenticator.Token(request.Context(), state, request, flow.tokenOptions...)
 		if err != nil {
 			clientChannel <- nil
 			errChannel <- errors.New(http.StatusText(http.StatusForbidden))
@@ -94,7 +143,7 @@ func Authenticate(urlProcessor func(string) error) (*Client, error) {
 			errChannel <- errors.New(http.StatusText(http.StatusNotFound))
 		} else {
 			client := spotify.New(
-				authenticator.Client(request.Context(), token),
+				cached(authenticator.Client(request.Context(), token)),
 				spotify.WithRetry(true),
 			)
 			clientChannel <- client
@@ -117,7 +166,7 @@ func Authenticate(urlProcessor func(string) error) (*Client, error) {
 				return
 			}
 
-			if err := urlProcessor(authenticator.AuthURL(state)); err != nil {
+			if err := urlProcessor(authenticator.AuthURL(state, flow.authOptions...)); err != nil {
 				ch <- err
 			}
 		},
@@ -138,6 +187,64 @@ func Authenticate(urlProcessor func(string) error) (*Client, error) {
 	return &client, client.Persist()
 }
 
+// headless authentication spawns no callback server: the URL the browser
+// got redirected to, or just its code, is read from the given reader instead
+func AuthenticateHeadless(urlProcessor func(string) error, reader io.Reader) (*Client, error) {
+	flow, err := newAuthFlow()
+	if err != nil {
+		return nil, err
+	}
+	if client, err := Recover(flow.authenticator, flow.state); err == nil {
+		return client, client.Persist()
+	}
+
+	if err := urlProcessor(flow.authenticator.AuthURL(flow.state, flow.authOptions...)); err != nil {
+		return nil, err
+	}
+
+	input, err := bufio.NewReader(reader).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	code, err := flow.code(strings.TrimSpace(input))
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := flow.authenticator.Exchange(context.Background(), code, flow.tokenOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &Client{spotify.New(
+		cached(flow.authenticator.Client(context.Background(), token)),
+		spotify.WithRetry(true),
+	), flow.authenticator, flow.state, make(map[string]interface{})}
+	return client, client.Persist()
+}
+
+// the redirected URL carries the code along with the state, which
+// must match the issued one, while a bare code is taken as it is
+func (flow *authFlow) code(input string) (string, error) {
+	if len(input) == 0 {
+		return "", errors.New("no authorization code given")
+	}
+
+	redirect, err := url.Parse(input)
+	if err != nil || !redirect.Query().Has("code") && !redirect.Query().Has("error") {
+		return input, nil
+	}
+
+	query := redirect.Query()
+	if reason := query.Get("error"); len(reason) > 0 {
+		return "", errors.New("authorization denied: " + reason)
+	}
+	if query.Get("state") != flow.state {
+		return "", errors.New("authorization state mismatch")
+	}
+	return query.Get("code"), nil
+}
+
 func Recover(authenticator *spotifyauth.Authenticator, state string) (*Client, error) {
 	data, err := os.ReadFile(tokenPath)
 	if err != nil {
@@ -150,7 +257,7 @@ func Recover(authenticator *spotifyauth.Authenticator, state string) (*Client, e
 	}
 
 	client := &Client{spotify.New(
-		authenticator.Client(context.Background(), &token),
+		cached(authenticator.Client(context.Background(), &token)),
 		spotify.WithRetry(true),
 	), authenticator, state, make(map[string]interface{})}
 
@@ -1,6 +1,7 @@
 package spotify
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"errors"
@@ -8,6 +9,7 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -20,6 +22,7 @@ import (
 	"github.com/thanhpk/randstr"
 	"github.com/zmb3/spotify/v2"
 	spotifyauth "github.com/zmb3/spotify/v2/auth"
+	"golang.org/x/oauth2"
 )
 
 const (
@@ -124,7 +127,7 @@ func TestAuthenticateNoClientID(t *testing.T) {
 	assert.Error(t, sys.ErrOnly(Authenticate(nil)))
 }
 
-func TestAuthenticateNoClientSecret(t *testing.T) {
+func TestAuthenticatePKCE(t *testing.T) {
 	t.Cleanup(resetPort)
 	port = getPort()
 
@@ -136,9 +139,40 @@ func TestAuthenticateNoClientSecret(t *testing.T) {
 		}
 		return "value"
 	}).Build()
+	mockey.Mock(Recover).Return(nil, errors.New("ko")).Build()
+	mockey.Mock(mockey.GetMethod(&Client{}, "Persist")).Return(nil).Build()
+	mockey.Mock(randstr.String).Return(state).Build()
+	mockey.Mock(mockey.GetMethod(spotifyauth.Authenticator{}, "Token")).To(func(_ context.Context, _ string, _ *http.Request, opts ...oauth2.AuthCodeOption) (*oauth2.Token, error) {
+		assert.Len(t, opts, 1)
+		return nil, nil
+	}).Build()
 
 	// testing
-	assert.Error(t, sys.ErrOnly(Authenticate(nil)))
+	assert.Nil(t, nursery.RunConcurrently(
+		func(_ context.Context, ch chan error) {
+			ch <- sys.ErrOnly(Authenticate(func(url string) error {
+				assert.Contains(t, url, "code_challenge_method=S256")
+				return nil
+			}))
+		},
+		func(_ context.Context, _ chan error) {
+			for {
+				// wait until the server is actually listening before sending the request
+				conn, dialErr := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port), 10*time.Millisecond)
+				if dialErr != nil {
+					time.Sleep(100 * time.Millisecond)
+					continue
+				}
+				conn.Close()
+				response, err := httpClient.Get(fmt.Sprintf("http://127.0.0.1:%d/callback?code=C0D3&state=%s", port, state))
+				if assert.Nil(t, err) {
+					assert.Equal(t, http.StatusOK, response.StatusCode)
+					response.Body.Close()
+				}
+				break
+			}
+		},
+	))
 }
 
 func TestAuthenticateRecoverAndPersist(t *testing.T) {
@@ -551,3 +585,120 @@ func TestAuthenticateServerUnserving(t *testing.T) {
 	// testing
 	assert.EqualError(t, sys.ErrOnly(Authenticate(nil)), "ko")
 }
+
+func TestSetRedirect(t *testing.T) {
+	t.Cleanup(func() { SetRedirect("127.0.0.1", 65535) })
+
+	// testing
+	SetRedirect("192.168.1.10", 8080)
+	assert.Equal(t, "192.168.1.10", redirectHost)
+	assert.Equal(t, 8080, port)
+	SetRedirect("", 0)
+	assert.Equal(t, "192.168.1.10", redirectHost)
+	assert.Equal(t, 8080, port)
+}
+
+func TestAuthenticateHeadless(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(os.Getenv).Return("value").Build()
+	mockey.Mock(Recover).Return(nil, errors.New("ko")).Build()
+	mockey.Mock(mockey.GetMethod(&Client{}, "Persist")).Return(nil).Build()
+	mockey.Mock(randstr.String).Return(state).Build()
+	mockey.Mock(mockey.GetMethod(spotifyauth.Authenticator{}, "Exchange")).To(func(_ context.Context, code string, _ ...oauth2.AuthCodeOption) (*oauth2.Token, error) {
+		assert.Equal(t, "C0D3", code)
+		return &oauth2.Token{}, nil
+	}).Build()
+
+	// testing
+	for _, input := range []string{
+		fmt.Sprintf("http://127.0.0.1:65535/callback?code=C0D3&state=%s\n", state),
+		"C0D3",
+		"  C0D3  \n",
+	} {
+		assert.Nil(t, sys.ErrOnly(AuthenticateHeadless(func(url string) error {
+			assert.Contains(t, url, "redirect_uri=")
+			return nil
+		}, strings.NewReader(input))))
+	}
+}
+
+func TestAuthenticateHeadlessRecover(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(os.Getenv).Return("value").Build()
+	mockey.Mock(Recover).Return(testClient(), nil).Build()
+	mockey.Mock(mockey.GetMethod(&Client{}, "Persist")).Return(nil).Build()
+
+	// testing
+	assert.Nil(t, sys.ErrOnly(AuthenticateHeadless(nil, strings.NewReader(""))))
+}
+
+func TestAuthenticateHeadlessNoClientID(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(os.Getenv).Return("").Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(AuthenticateHeadless(nil, strings.NewReader(""))), "SPOTIFY_ID not set")
+}
+
+func TestAuthenticateHeadlessInvalidInput(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(os.Getenv).Return("value").Build()
+	mockey.Mock(Recover).Return(nil, errors.New("ko")).Build()
+	mockey.Mock(randstr.String).Return(state).Build()
+
+	// testing
+	for input, err := range map[string]string{
+		"":                                       "no authorization code given",
+		"http://127.0.0.1/callback?error=denied": "authorization denied: denied",
+		"http://127.0.0.1/callback?code=C0D3&state": "authorization state mismatch",
+	} {
+		assert.EqualError(t, sys.ErrOnly(AuthenticateHeadless(func(string) error { return nil }, strings.NewReader(input))), err)
+	}
+}
+
+func TestAuthenticateHeadlessProcessorFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(os.Getenv).Return("value").Build()
+	mockey.Mock(Recover).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(AuthenticateHeadless(func(string) error { return errors.New("ko") }, strings.NewReader(""))), "ko")
+}
+
+func TestAuthenticateHeadlessReadFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(os.Getenv).Return("value").Build()
+	mockey.Mock(Recover).Return(nil, errors.New("ko")).Build()
+	mockey.Mock((*bufio.Reader).ReadString).Return("", errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(AuthenticateHeadless(func(string) error { return nil }, strings.NewReader(""))), "ko")
+}
+
+func TestAuthenticateHeadlessExchangeFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(os.Getenv).Return("value").Build()
+	mockey.Mock(Recover).Return(nil, errors.New("ko")).Build()
+	mockey.Mock(mockey.GetMethod(spotifyauth.Authenticator{}, "Exchange")).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(AuthenticateHeadless(func(string) error { return nil }, strings.NewReader("%zz"))), "ko")
+}
+
+func TestSetProfile(t *testing.T) {
+	t.Cleanup(func() { SetProfile("") })
+
+	// testing
+	SetProfile("alice")
+	assert.Equal(t, sys.ProfileCacheFile("alice", TokenBasename), TokenPath())
+	assert.Equal(t, sys.ProfileCacheFile("alice", CacheBasename), cachePath)
+	SetProfile("")
+	assert.Equal(t, sys.CacheFile(TokenBasename), TokenPath())
+}
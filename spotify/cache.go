@@ -0,0 +1,169 @@
+package spotify
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/streambinder/spotitube/sys"
+	"golang.org/x/oauth2"
+)
+
+const CacheBasename = "responses"
+
+var (
+	cachePath = sys.CacheFile(CacheBasename)
+	cacheTTL  = 7 * 24 * time.Hour
+	// tracks and albums never change once released, so they are served straight
+	// from the cache until they expire, while any other resource, such as the
+	// releases of an artist, is revalidated via its ETag
+	cacheCatalog = regexp.MustCompile(`^/v1/(tracks(/[^/]+)?|albums/[^/]+)$`)
+	// folders already swept of expired entries, in this process
+	cacheEvicted sync.Map
+)
+
+type cacheEntry struct {
+	ETag   string      `json:"etag,omitempty"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+	Time   time.Time   `json:"time"`
+}
+
+// responses to GET requests are cached on disk, beneath the
+// oauth2 transport, which the client relies on for its token
+type cacheTransport struct {
+	base http.RoundTripper
+	path string
+	ttl  time.Duration
+}
+
+func cached(client *http.Client) *http.Client {
+	if transport, ok := client.Transport.(*oauth2.Transport); ok {
+		base := transport.Base
+		if base == nil {
+			base = http.DefaultTransport
+		}
+		transport.Base = &cacheTransport{base, cachePath, cacheTTL}
+	}
+	return client
+}
+
+func (transport *cacheTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if request.Method != http.MethodGet {
+		return transport.base.RoundTrip(request)
+	}
+
+	var (
+		path    = filepath.Join(transport.path, cacheKey(request.URL.String()))
+		catalog = cacheIsCatalog(request.URL.Path)
+		entry   = cacheLoad(path)
+	)
+	if entry != nil && catalog && time.Since(entry.Time) < transport.ttl {
+		return entry.response(request), nil
+	}
+	if entry != nil && len(entry.ETag) > 0 {
+		request = request.Clone(request.Context())
+		request.Header.Set("If-None-Match", entry.ETag)
+	}
+
+	response, err := transport.base.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case response.StatusCode == http.StatusNotModified && entry != nil:
+		response.Body.Close()
+		entry.Time = time.Now()
+		transport.store(path, entry)
+		return entry.response(request), nil
+	case response.StatusCode == http.StatusOK && (catalog || len(response.Header.Get("ETag")) > 0):
+		body, err := io.ReadAll(response.Body)
+		response.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		transport.store(path, &cacheEntry{response.Header.Get("ETag"), response.Header, body, time.Now()})
+		response.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return response, nil
+}
+
+func (entry *cacheEntry) response(request *http.Request) *http.Response {
+	return &http.Response{
+		Status:        http.StatusText(http.StatusOK),
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        entry.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(entry.Body)),
+		ContentLength: int64(len(entry.Body)),
+		Request:       request,
+	}
+}
+
+func cacheKey(url string) string {
+	hash := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(hash[:]) + ".json"
+}
+
+func cacheIsCatalog(path string) bool {
+	return cacheCatalog.MatchString(path)
+}
+
+// missing or unreadable entries are plain cache misses
+func cacheLoad(path string) *cacheEntry {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil
+	}
+	return &entry
+}
+
+// entries not stored for longer than the TTL are dropped
+// on the first store, so that the folder does not grow forever
+func (transport *cacheTransport) store(path string, entry *cacheEntry) {
+	if _, evicted := cacheEvicted.LoadOrStore(transport.path, true); !evicted {
+		cacheEvict(transport.path, transport.ttl)
+	}
+	cacheStore(path, entry)
+}
+
+func cacheEvict(path string, ttl time.Duration) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if info, err := entry.Info(); err == nil && !entry.IsDir() && time.Since(info.ModTime()) >= ttl {
+			sys.ErrSuppress(os.Remove(filepath.Join(path, entry.Name())))
+		}
+	}
+}
+
+// the cache is best-effort: failing to store an entry
+// does not fail the request it belongs to
+func cacheStore(path string, entry *cacheEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+	sys.ErrSuppress(os.WriteFile(path, data, 0o600))
+}
@@ -0,0 +1,216 @@
+package spotify
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bytedance/mockey"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+type testRoundTripper func(*http.Request) (*http.Response, error)
+
+func (roundTripper testRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	return roundTripper(request)
+}
+
+type testFailingReader struct{}
+
+func (testFailingReader) Read([]byte) (int, error) {
+	return 0, errors.New("ko")
+}
+
+func testResponse(status int, etag, body string) *http.Response {
+	header := http.Header{}
+	if len(etag) > 0 {
+		header.Set("ETag", etag)
+	}
+	return &http.Response{StatusCode: status, Header: header, Body: io.NopCloser(strings.NewReader(body))}
+}
+
+func testCacheGet(t *testing.T, transport http.RoundTripper, url string) string {
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	assert.Nil(t, err)
+	response, err := transport.RoundTrip(request)
+	assert.Nil(t, err)
+	body, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+	assert.Nil(t, response.Body.Close())
+	return string(body)
+}
+
+func BenchmarkCache(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		TestCacheCatalog(&testing.T{})
+	}
+}
+
+func TestCached(t *testing.T) {
+	client := cached(&http.Client{Transport: &oauth2.Transport{}})
+	assert.Equal(t, http.DefaultTransport, client.Transport.(*oauth2.Transport).Base.(*cacheTransport).base)
+	assert.Equal(t, http.DefaultTransport, cached(&http.Client{Transport: http.DefaultTransport}).Transport)
+}
+
+func TestCacheCatalog(t *testing.T) {
+	var (
+		requests  []*http.Request
+		transport = &cacheTransport{testRoundTripper(func(request *http.Request) (*http.Response, error) {
+			requests = append(requests, request)
+			if len(request.Header.Get("If-None-Match")) > 0 {
+				return testResponse(http.StatusNotModified, "", ""), nil
+			}
+			return testResponse(http.StatusOK, "etag", "track"), nil
+		}), t.TempDir(), time.Hour}
+	)
+
+	// testing
+	assert.Equal(t, "track", testCacheGet(t, transport, "https://api.spotify.com/v1/tracks/123"))
+	assert.Equal(t, "track", testCacheGet(t, transport, "https://api.spotify.com/v1/tracks/123"))
+	assert.Len(t, requests, 1)
+	transport.ttl = 0
+	assert.Equal(t, "track", testCacheGet(t, transport, "https://api.spotify.com/v1/tracks/123"))
+	assert.Len(t, requests, 2)
+	assert.Equal(t, "etag", requests[1].Header.Get("If-None-Match"))
+}
+
+func TestCacheRevalidation(t *testing.T) {
+	var (
+		requests  int
+		transport = &cacheTransport{testRoundTripper(func(request *http.Request) (*http.Response, error) {
+			requests++
+			switch {
+			case strings.HasSuffix(request.URL.Path, "/player"):
+				return testResponse(http.StatusOK, "", "player"), nil
+			case len(request.Header.Get("If-None-Match")) > 0:
+				return testResponse(http.StatusNotModified, "", ""), nil
+			}
+			return testResponse(http.StatusOK, "etag", "library"), nil
+		}), t.TempDir(), time.Hour}
+	)
+
+	// testing
+	assert.Equal(t, "library", testCacheGet(t, transport, "https://api.spotify.com/v1/me/tracks"))
+	assert.Equal(t, "library", testCacheGet(t, transport, "https://api.spotify.com/v1/me/tracks"))
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, "player", testCacheGet(t, transport, "https://api.spotify.com/v1/me/player"))
+	assert.Equal(t, "player", testCacheGet(t, transport, "https://api.spotify.com/v1/me/player"))
+	assert.Equal(t, 4, requests)
+	entries, err := os.ReadDir(transport.path)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestCacheNotGet(t *testing.T) {
+	var transport = &cacheTransport{testRoundTripper(func(*http.Request) (*http.Response, error) {
+		return testResponse(http.StatusCreated, "", ""), nil
+	}), t.TempDir(), time.Hour}
+
+	// testing
+	request, err := http.NewRequest(http.MethodPost, "https://api.spotify.com/v1/me/tracks", nil)
+	assert.Nil(t, err)
+	response, err := transport.RoundTrip(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+}
+
+func TestCacheCorruptEntry(t *testing.T) {
+	var transport = &cacheTransport{testRoundTripper(func(*http.Request) (*http.Response, error) {
+		return testResponse(http.StatusOK, "", "track"), nil
+	}), t.TempDir(), time.Hour}
+	assert.Nil(t, os.WriteFile(filepath.Join(transport.path, cacheKey("https://api.spotify.com/v1/tracks/123")), []byte("{"), 0o600))
+
+	// testing
+	assert.Equal(t, "track", testCacheGet(t, transport, "https://api.spotify.com/v1/tracks/123"))
+}
+
+func TestCacheFailure(t *testing.T) {
+	var transport = &cacheTransport{testRoundTripper(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("ko")
+	}), t.TempDir(), time.Hour}
+
+	// testing
+	request, err := http.NewRequest(http.MethodGet, "https://api.spotify.com/v1/tracks/123", nil)
+	assert.Nil(t, err)
+	_, err = transport.RoundTrip(request)
+	assert.EqualError(t, err, "ko")
+}
+
+func TestCacheReadFailure(t *testing.T) {
+	var transport = &cacheTransport{testRoundTripper(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(testFailingReader{})}, nil
+	}), t.TempDir(), time.Hour}
+
+	// testing
+	request, err := http.NewRequest(http.MethodGet, "https://api.spotify.com/v1/tracks/123", nil)
+	assert.Nil(t, err)
+	_, err = transport.RoundTrip(request)
+	assert.EqualError(t, err, "ko")
+}
+
+func TestCacheStoreFailure(t *testing.T) {
+	var (
+		path      = filepath.Join(t.TempDir(), "file")
+		transport = &cacheTransport{testRoundTripper(func(*http.Request) (*http.Response, error) {
+			return testResponse(http.StatusOK, "", "track"), nil
+		}), filepath.Join(path, "responses"), time.Hour}
+	)
+	assert.Nil(t, os.WriteFile(path, []byte{}, 0o600))
+
+	// testing
+	assert.Equal(t, "track", testCacheGet(t, transport, "https://api.spotify.com/v1/tracks/123"))
+}
+
+func TestCacheStoreMarshalFailure(t *testing.T) {
+	var transport = &cacheTransport{testRoundTripper(func(*http.Request) (*http.Response, error) {
+		return testResponse(http.StatusOK, "", "track"), nil
+	}), t.TempDir(), time.Hour}
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(json.Marshal).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.Equal(t, "track", testCacheGet(t, transport, "https://api.spotify.com/v1/tracks/123"))
+	entries, err := os.ReadDir(transport.path)
+	assert.Nil(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestCacheIsCatalog(t *testing.T) {
+	assert.True(t, cacheIsCatalog("/v1/tracks"))
+	assert.True(t, cacheIsCatalog("/v1/tracks/123"))
+	assert.True(t, cacheIsCatalog("/v1/albums/123"))
+	assert.False(t, cacheIsCatalog("/v1/albums/123/tracks"))
+	assert.False(t, cacheIsCatalog("/v1/artists/123"))
+	assert.False(t, cacheIsCatalog("/v1/artists/123/albums"))
+	assert.False(t, cacheIsCatalog("/v1/me/tracks"))
+}
+
+func TestCacheEviction(t *testing.T) {
+	var transport = &cacheTransport{testRoundTripper(func(*http.Request) (*http.Response, error) {
+		return testResponse(http.StatusOK, "etag", "releases"), nil
+	}), t.TempDir(), time.Hour}
+	var (
+		expired = filepath.Join(transport.path, "expired.json")
+		fresh   = filepath.Join(transport.path, "fresh.json")
+	)
+	assert.Nil(t, os.WriteFile(expired, []byte("{}"), 0o600))
+	assert.Nil(t, os.WriteFile(fresh, []byte("{}"), 0o600))
+	assert.Nil(t, os.Chtimes(expired, time.Now().Add(-2*time.Hour), time.Now().Add(-2*time.Hour)))
+	assert.Nil(t, os.Mkdir(filepath.Join(transport.path, "folder"), 0o755))
+
+	// testing
+	assert.Equal(t, "releases", testCacheGet(t, transport, "https://api.spotify.com/v1/artists/123/albums"))
+	assert.NoFileExists(t, expired)
+	assert.FileExists(t, fresh)
+	assert.DirExists(t, filepath.Join(transport.path, "folder"))
+	assert.FileExists(t, filepath.Join(transport.path, cacheKey("https://api.spotify.com/v1/artists/123/albums")))
+}
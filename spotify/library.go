@@ -7,6 +7,8 @@ import (
 	"github.com/zmb3/spotify/v2"
 )
 
+const libraryBatchSize = 50 // max number of tracks checkable or savable at once
+
 func (client *Client) Library(limit int, channels ...chan interface{}) error {
 	var (
 		ctx          = context.Background()
@@ -37,3 +39,64 @@ func (client *Client) Library(limit int, channels ...chan interface{}) error {
 
 	return nil
 }
+
+// saved albums are the ones in the user's "Your Library → Albums"
+func (client *Client) SavedAlbums(channels ...chan interface{}) error {
+	var (
+		ctx         = context.Background()
+		albums, err = client.CurrentUsersAlbums(ctx)
+	)
+	if err != nil {
+		return err
+	}
+
+	for {
+		for _, savedAlbum := range albums.Albums {
+			if _, err := client.album(&savedAlbum.FullAlbum, channels...); err != nil {
+				return err
+			}
+		}
+
+		if err := client.NextPage(ctx, albums); errors.Is(err, spotify.ErrNoMorePages) {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// tells which of the given tracks are saved in the user's library
+func (client *Client) LibraryContains(ids []string) (map[string]bool, error) {
+	saved := make(map[string]bool)
+	for i := 0; i < len(ids); i += libraryBatchSize {
+		batch := ids[i:min(i+libraryBatchSize, len(ids))]
+		contained, err := client.UserHasTracks(context.Background(), libraryIDs(batch)...)
+		if err != nil {
+			return nil, err
+		}
+		for j, ok := range contained {
+			saved[batch[j]] = ok
+		}
+	}
+	return saved, nil
+}
+
+func (client *Client) LibraryAdd(ids []string) error {
+	for i := 0; i < len(ids); i += libraryBatchSize {
+		if err := client.AddTracksToLibrary(context.Background(),
+			libraryIDs(ids[i:min(i+libraryBatchSize, len(ids))])...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func libraryIDs(targets []string) []spotify.ID {
+	ids := make([]spotify.ID, 0, len(targets))
+	for _, target := range targets {
+		ids = append(ids, id(target))
+	}
+	return ids
+}
@@ -1,7 +1,9 @@
 package spotify
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/bytedance/mockey"
@@ -63,3 +65,113 @@ func TestLibraryNextPageFailure(t *testing.T) {
 	// testing
 	assert.EqualError(t, sys.ErrOnly(testClient().Library(0)), "ko")
 }
+
+func TestSavedAlbums(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "CurrentUsersAlbums")).Return(&spotify.SavedAlbumPage{
+		Albums: []spotify.SavedAlbum{{FullAlbum: *fullAlbum}},
+	}, nil).Build()
+
+	// testing
+	channel := make(chan interface{}, 1)
+	defer close(channel)
+	assert.Nil(t, testClient().SavedAlbums(channel))
+	track := (<-channel).(*entity.Track)
+	assert.Equal(t, fullAlbum.Tracks.Tracks[0].Name, track.Title)
+	assert.Equal(t, fullAlbum.Name, track.Album)
+}
+
+func TestSavedAlbumsFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "CurrentUsersAlbums")).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, testClient().SavedAlbums(), "ko")
+}
+
+func TestSavedAlbumsAlbumFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "CurrentUsersAlbums")).Return(&spotify.SavedAlbumPage{
+		Albums: []spotify.SavedAlbum{{FullAlbum: *fullAlbum}},
+	}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "NextPage")).Return(errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, testClient().SavedAlbums(), "ko")
+}
+
+func TestSavedAlbumsNextPageFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "CurrentUsersAlbums")).Return(&spotify.SavedAlbumPage{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "NextPage")).Return(errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, testClient().SavedAlbums(), "ko")
+}
+
+func testLibraryIDs(size int) []string {
+	var ids []string
+	for i := range size {
+		ids = append(ids, fmt.Sprintf("spotify:track:%d", i))
+	}
+	return ids
+}
+
+func TestLibraryContains(t *testing.T) {
+	var batches []int
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "UserHasTracks")).To(func(_ context.Context, ids ...spotify.ID) ([]bool, error) {
+		batches = append(batches, len(ids))
+		contained := make([]bool, len(ids))
+		contained[0] = true
+		return contained, nil
+	}).Build()
+
+	// testing
+	saved, err := testClient().LibraryContains(testLibraryIDs(60))
+	assert.Nil(t, err)
+	assert.Equal(t, []int{50, 10}, batches)
+	assert.Len(t, saved, 60)
+	assert.True(t, saved["spotify:track:0"])
+	assert.True(t, saved["spotify:track:50"])
+	assert.False(t, saved["spotify:track:1"])
+}
+
+func TestLibraryContainsFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "UserHasTracks")).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testClient().LibraryContains(testLibraryIDs(1))), "ko")
+}
+
+func TestLibraryAdd(t *testing.T) {
+	var batches []int
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "AddTracksToLibrary")).To(func(_ context.Context, ids ...spotify.ID) error {
+		batches = append(batches, len(ids))
+		return nil
+	}).Build()
+
+	// testing
+	assert.Nil(t, testClient().LibraryAdd(testLibraryIDs(120)))
+	assert.Equal(t, []int{50, 50, 20}, batches)
+}
+
+func TestLibraryAddFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "AddTracksToLibrary")).Return(errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, testClient().LibraryAdd(testLibraryIDs(1)), "ko")
+}
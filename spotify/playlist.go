@@ -11,6 +11,7 @@ import (
 
 const (
 	personalPlaylistsCacheID = "PersonalPlaylists"
+	playlistBatchSize        = 100 // max number of tracks addable at once
 )
 
 func playlistEntity(fullPlaylist spotify.FullPlaylist) *playlist.Playlist {
@@ -19,6 +20,7 @@ func playlistEntity(fullPlaylist spotify.FullPlaylist) *playlist.Playlist {
 		Name:          fullPlaylist.Name,
 		Owner:         fullPlaylist.Owner.ID,
 		Collaborative: fullPlaylist.Collaborative,
+		SnapshotID:    fullPlaylist.SnapshotID,
 	}
 }
 
@@ -67,6 +69,11 @@ func (client *Client) personalPlaylists() ([]*playlist.Playlist, error) {
 	return playlists, nil
 }
 
+// playlists owned or followed by the user, without their tracks
+func (client *Client) Playlists() ([]*playlist.Playlist, error) {
+	return client.personalPlaylists()
+}
+
 func (client *Client) Playlist(target string, channels ...chan interface{}) (*playlist.Playlist, error) {
 	var (
 		ctx     = context.Background()
@@ -101,3 +108,66 @@ func (client *Client) Playlist(target string, channels ...chan interface{}) (*pl
 
 	return playlist, nil
 }
+
+// only fetches the playlist details, without its tracks,
+// to tell whether its contents changed since the last time
+func (client *Client) PlaylistSnapshot(target string) (*playlist.Playlist, error) {
+	id, err := client.personalPlaylistNameToID(target)
+	if err != nil {
+		return nil, err
+	}
+
+	fullPlaylist, err := client.GetPlaylist(context.Background(), id, spotify.Fields("id,name,owner(id),collaborative,snapshot_id"))
+	if err != nil {
+		return nil, err
+	}
+	return playlistEntity(*fullPlaylist), nil
+}
+
+// the playlist owned by the user and named after the given one gets
+// its tracks replaced by the given ones, or created if none exists
+func (client *Client) PushPlaylist(name string, ids []string) (*playlist.Playlist, error) {
+	ctx := context.Background()
+	username, err := client.Username()
+	if err != nil {
+		return nil, err
+	}
+
+	personalPlaylists, err := client.personalPlaylists()
+	if err != nil {
+		return nil, err
+	}
+
+	var target *playlist.Playlist
+	for _, personalPlaylist := range personalPlaylists {
+		if personalPlaylist.Owner == username && personalPlaylist.Name == name {
+			target = personalPlaylist
+			break
+		}
+	}
+	if target == nil {
+		fullPlaylist, err := client.CreatePlaylistForUser(ctx, username, name, "", false, false)
+		if err != nil {
+			return nil, err
+		}
+		target = playlistEntity(*fullPlaylist)
+	}
+
+	trackIDs := make([]spotify.ID, 0, len(ids))
+	for _, trackID := range ids {
+		trackIDs = append(trackIDs, id(trackID))
+	}
+	// the first batch replaces the tracks, even if empty, the others get appended
+	for i := 0; i == 0 || i < len(trackIDs); i += playlistBatchSize {
+		batch := trackIDs[i:min(i+playlistBatchSize, len(trackIDs))]
+		if i == 0 {
+			err = client.ReplacePlaylistTracks(ctx, id(target.ID), batch...)
+		} else {
+			_, err = client.AddTracksToPlaylist(ctx, id(target.ID), batch...)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return target, nil
+}
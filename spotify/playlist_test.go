@@ -1,7 +1,9 @@
 package spotify
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/bytedance/mockey"
@@ -12,9 +14,10 @@ import (
 
 var fullPlaylist = &spotify.FullPlaylist{
 	SimplePlaylist: spotify.SimplePlaylist{
-		ID:    spotify.ID("123"),
-		Name:  "Playlist",
-		Owner: spotify.User{ID: "User"},
+		ID:         spotify.ID("123"),
+		Name:       "Playlist",
+		Owner:      spotify.User{ID: "User"},
+		SnapshotID: "snapshot",
 	},
 	Tracks: spotify.PlaylistTrackPage{
 		Tracks: []spotify.PlaylistTrack{
@@ -46,6 +49,7 @@ func TestPlaylist(t *testing.T) {
 	assert.Equal(t, fullPlaylist.ID.String(), playlist.ID)
 	assert.Equal(t, fullPlaylist.Name, playlist.Name)
 	assert.Equal(t, fullPlaylist.Owner.ID, playlist.Owner)
+	assert.Equal(t, fullPlaylist.SnapshotID, playlist.SnapshotID)
 	assert.Equal(t, len(fullPlaylist.Tracks.Tracks), len(playlist.Tracks))
 	assert.Equal(t, fullPlaylist.Tracks.Tracks[0].Track.ID.String(), playlist.Tracks[0].ID)
 	assert.Equal(t, fullPlaylist.Tracks.Tracks[0].Track.Name, playlist.Tracks[0].Title)
@@ -113,3 +117,134 @@ func TestPlaylistGetPlaylistNextPageFailure(t *testing.T) {
 	// testing
 	assert.EqualError(t, sys.ErrOnly(client.Playlist(fullPlaylist.ID.String())), "ko")
 }
+
+func TestPlaylistSnapshot(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "CurrentUsersPlaylists")).Return(&spotify.SimplePlaylistPage{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "GetPlaylist")).Return(fullPlaylist, nil).Build()
+
+	// testing
+	playlist, err := testClient().PlaylistSnapshot(fullPlaylist.ID.String())
+	assert.Nil(t, err)
+	assert.Equal(t, fullPlaylist.ID.String(), playlist.ID)
+	assert.Equal(t, fullPlaylist.SnapshotID, playlist.SnapshotID)
+	assert.Empty(t, playlist.Tracks)
+}
+
+func TestPlaylistSnapshotCurrentUsersPlaylistsFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "CurrentUsersPlaylists")).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testClient().PlaylistSnapshot(fullPlaylist.ID.String())), "ko")
+}
+
+func TestPlaylistSnapshotGetPlaylistFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "CurrentUsersPlaylists")).Return(&spotify.SimplePlaylistPage{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "GetPlaylist")).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testClient().PlaylistSnapshot(fullPlaylist.ID.String())), "ko")
+}
+
+func TestPlaylists(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "CurrentUsersPlaylists")).Return(&spotify.SimplePlaylistPage{
+		Playlists: []spotify.SimplePlaylist{fullPlaylist.SimplePlaylist},
+	}, nil).Build()
+
+	// testing
+	playlists, err := testClient().Playlists()
+	assert.Nil(t, err)
+	assert.Len(t, playlists, 1)
+	assert.Equal(t, fullPlaylist.Name, playlists[0].Name)
+	assert.Equal(t, fullPlaylist.SnapshotID, playlists[0].SnapshotID)
+}
+
+func TestPushPlaylist(t *testing.T) {
+	var replaced, added []int
+
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "CurrentUser")).Return(&spotify.PrivateUser{User: spotify.User{ID: "User"}}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "CurrentUsersPlaylists")).Return(&spotify.SimplePlaylistPage{
+		Playlists: []spotify.SimplePlaylist{
+			{ID: "456", Name: "Playlist", Owner: spotify.User{ID: "Other"}},
+			{ID: "123", Name: "Playlist", Owner: spotify.User{ID: "User"}},
+		},
+	}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "CreatePlaylistForUser")).Return(&spotify.FullPlaylist{
+		SimplePlaylist: spotify.SimplePlaylist{ID: "789", Name: "New", Owner: spotify.User{ID: "User"}},
+	}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "ReplacePlaylistTracks")).To(func(_ context.Context, playlistID spotify.ID, ids ...spotify.ID) error {
+		replaced = append(replaced, len(ids))
+		return nil
+	}).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "AddTracksToPlaylist")).To(func(_ context.Context, playlistID spotify.ID, ids ...spotify.ID) (string, error) {
+		added = append(added, len(ids))
+		return "", nil
+	}).Build()
+
+	// testing
+	var ids []string
+	for i := range 250 {
+		ids = append(ids, fmt.Sprintf("spotify:track:%d", i))
+	}
+	playlist, err := testClient().PushPlaylist("Playlist", ids)
+	assert.Nil(t, err)
+	assert.Equal(t, "123", playlist.ID)
+	assert.Equal(t, []int{100}, replaced)
+	assert.Equal(t, []int{100, 50}, added)
+	playlist, err = testClient().PushPlaylist("New", []string{})
+	assert.Nil(t, err)
+	assert.Equal(t, "789", playlist.ID)
+	assert.Equal(t, []int{100, 0}, replaced)
+}
+
+func TestPushPlaylistUsernameFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "CurrentUser")).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testClient().PushPlaylist("Playlist", []string{})), "ko")
+}
+
+func TestPushPlaylistCurrentUsersPlaylistsFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "CurrentUser")).Return(&spotify.PrivateUser{User: spotify.User{ID: "User"}}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "CurrentUsersPlaylists")).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testClient().PushPlaylist("Playlist", []string{})), "ko")
+}
+
+func TestPushPlaylistCreateFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "CurrentUser")).Return(&spotify.PrivateUser{User: spotify.User{ID: "User"}}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "CurrentUsersPlaylists")).Return(&spotify.SimplePlaylistPage{}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "CreatePlaylistForUser")).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testClient().PushPlaylist("Playlist", []string{})), "ko")
+}
+
+func TestPushPlaylistReplaceFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "CurrentUser")).Return(&spotify.PrivateUser{User: spotify.User{ID: "User"}}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "CurrentUsersPlaylists")).Return(&spotify.SimplePlaylistPage{
+		Playlists: []spotify.SimplePlaylist{{ID: "123", Name: "Playlist", Owner: spotify.User{ID: "User"}}},
+	}, nil).Build()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "ReplacePlaylistTracks")).Return(errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testClient().PushPlaylist("Playlist", []string{"123"})), "ko")
+}
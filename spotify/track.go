@@ -2,6 +2,7 @@ package spotify
 
 import (
 	"context"
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -23,6 +24,12 @@ func trackEntity(track spotify.FullTrack) *entity.Track {
 			return flatArtists
 		}(track.Artists),
 		Album: track.Album.Name,
+		AlbumArtist: func(artists []spotify.SimpleArtist) string {
+			for _, artist := range artists {
+				return artist.Name
+			}
+			return ""
+		}(track.Album.Artists),
 		Artwork: entity.Artwork{
 			URL: func(artworks []spotify.Image) string {
 				for _, artwork := range artworks {
@@ -52,3 +59,17 @@ func (client *Client) Track(target string, channels ...chan interface{}) (*entit
 	}
 	return track, nil
 }
+
+// tracks missing their Spotify ID can be looked up
+// by title and artist, resolving to the most relevant one
+func (client *Client) SearchTrack(title, artist string) (*entity.Track, error) {
+	search, err := client.Search(context.Background(),
+		fmt.Sprintf("track:%q artist:%q", title, artist), spotify.SearchTypeTrack, spotify.Limit(1))
+	if err != nil {
+		return nil, err
+	}
+	if search.Tracks == nil || len(search.Tracks.Tracks) == 0 {
+		return nil, fmt.Errorf("track not found: %s by %s", title, artist)
+	}
+	return trackEntity(search.Tracks.Tracks[0]), nil
+}
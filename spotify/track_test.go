@@ -1,6 +1,7 @@
 package spotify
 
 import (
+	"context"
 	"errors"
 	"strconv"
 	"strings"
@@ -45,6 +46,7 @@ func TestTrack(t *testing.T) {
 	assert.Equal(t, fullTrack.Name, track.Title)
 	assert.Equal(t, len(fullTrack.Artists), len(track.Artists))
 	assert.Equal(t, len(fullTrack.Album.Name), len(track.Album))
+	assert.Empty(t, track.AlbumArtist)
 	assert.Equal(t, int(fullTrack.Duration)/1000, track.Duration)
 	assert.Equal(t, int(fullTrack.TrackNumber), track.Number)
 	assert.Equal(t, fullTrack.Album.Images[0].URL, track.Artwork.URL)
@@ -72,3 +74,35 @@ func TestTrackGetTrackFailure(t *testing.T) {
 	// testing
 	assert.EqualError(t, sys.ErrOnly(testClient().Track(fullTrack.ID.String())), "ko")
 }
+
+func TestSearchTrack(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Search")).To(func(_ context.Context, query string, _ spotify.SearchType, _ ...spotify.RequestOption) (*spotify.SearchResult, error) {
+		assert.Equal(t, `track:"Title" artist:"Artist"`, query)
+		return &spotify.SearchResult{Tracks: &spotify.FullTrackPage{Tracks: []spotify.FullTrack{fullTrack}}}, nil
+	}).Build()
+
+	// testing
+	track, err := testClient().SearchTrack("Title", "Artist")
+	assert.Nil(t, err)
+	assert.Equal(t, fullTrack.ID.String(), track.ID)
+}
+
+func TestSearchTrackNotFound(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Search")).Return(&spotify.SearchResult{}, nil).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testClient().SearchTrack("Title", "Artist")), "track not found: Title by Artist")
+}
+
+func TestSearchTrackFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&spotify.Client{}, "Search")).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(testClient().SearchTrack("Title", "Artist")), "ko")
+}
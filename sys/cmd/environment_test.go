@@ -38,6 +38,20 @@ func TestValidateEnvironmentNoFFmpeg(t *testing.T) {
 	assert.Error(t, ValidateEnvironment(), "command \"ffmpeg\" not found in PATH")
 }
 
+func TestValidateEnvironmentNoFFprobe(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(exec.LookPath).To(func(file string) (string, error) {
+		if file == "ffprobe" {
+			return "", fmt.Errorf("no ffprobe")
+		}
+		return "", nil
+	}).Build()
+
+	// testing
+	assert.EqualError(t, ValidateEnvironment(), "command \"ffprobe\" not found in PATH")
+}
+
 func TestValidateEnvironmentNoYtDlp(t *testing.T) {
 	// monkey patching
 	defer mockey.UnPatchAll()
@@ -2,12 +2,14 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -71,3 +73,82 @@ func (FFmpegCmd) VolumeAdd(path string, delta float64) error {
 	}
 	return os.Rename(temp, path)
 }
+
+// writes the given tags, and optionally the picture at the given path
+// as front cover, through a copy of the streams, with no re-encoding
+func (FFmpegCmd) Tag(path string, tags map[string]string, picture string) error {
+	var (
+		output bytes.Buffer
+		temp   = sys.FileBaseStem(path) + ".tag" + filepath.Ext(path)
+		args   = []string{"-i", path}
+		keys   = make([]string, 0, len(tags))
+	)
+	if len(picture) > 0 {
+		args = append(args, "-i", picture, "-map", "0:a", "-map", "1", "-disposition:v", "attached_pic")
+	} else {
+		args = append(args, "-map", "0")
+	}
+	args = append(args, "-codec", "copy")
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		args = append(args, "-metadata", key+"="+tags[key])
+	}
+	// MP4 atoms other than the well-known ones get dropped otherwise
+	if strings.EqualFold(filepath.Ext(path), ".m4a") {
+		args = append(args, "-movflags", "use_metadata_tags")
+	}
+
+	cmd := exec.Command("ffmpeg", append(args, "-y", temp)...) // nolint:gosec
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		return errors.New(output.String())
+	}
+	return os.Rename(temp, path)
+}
+
+// reads container and stream tags, keyed by lowercase name,
+// as Ogg streams carry them on their own, unlike other containers
+func (FFmpegCmd) Tags(path string) (map[string]string, error) {
+	var (
+		output bytes.Buffer
+		errput bytes.Buffer
+		probe  struct {
+			Format struct {
+				Tags map[string]string `json:"tags"`
+			} `json:"format"`
+			Streams []struct {
+				Tags map[string]string `json:"tags"`
+			} `json:"streams"`
+		}
+		cmd = exec.Command(
+			"ffprobe",
+			"-v", "error",
+			"-show_entries", "format_tags:stream_tags",
+			"-of", "json",
+			path,
+		)
+	)
+	cmd.Stdout = &output
+	cmd.Stderr = &errput
+	if err := cmd.Run(); err != nil {
+		return nil, errors.New(errput.String())
+	}
+	if err := json.Unmarshal(output.Bytes(), &probe); err != nil {
+		return nil, err
+	}
+
+	tags := make(map[string]string)
+	for _, stream := range probe.Streams {
+		for key, value := range stream.Tags {
+			tags[strings.ToLower(key)] = value
+		}
+	}
+	for key, value := range probe.Format.Tags {
+		tags[strings.ToLower(key)] = value
+	}
+	return tags, nil
+}
@@ -32,6 +32,7 @@ func BenchmarkFFmpeg(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		TestVolumeDetect(&testing.T{})
 		TestVolumeAdd(&testing.T{})
+		TestTag(&testing.T{})
 	}
 }
 
@@ -112,3 +113,99 @@ func TestVolumeAddFFmpegFailure(t *testing.T) {
 	// testing
 	assert.Error(t, FFmpeg().VolumeAdd("/dev/null", -1))
 }
+
+func TestTag(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	var args []string
+	mockey.Mock(mockey.GetMethod(&exec.Cmd{}, "Run")).To(func(cmd *exec.Cmd) error {
+		args = cmd.Args
+		return nil
+	}).Build()
+	mockey.Mock(os.Rename).Return(nil).Build()
+
+	// testing
+	assert.Nil(t, FFmpeg().Tag("track.flac", map[string]string{"title": "Title", "artist": "Artist"}, ""))
+	assert.Equal(t, []string{
+		"ffmpeg", "-i", "track.flac", "-map", "0", "-codec", "copy",
+		"-metadata", "artist=Artist", "-metadata", "title=Title",
+		"-y", "track.tag.flac",
+	}, args)
+}
+
+func TestTagPicture(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	var args []string
+	mockey.Mock(mockey.GetMethod(&exec.Cmd{}, "Run")).To(func(cmd *exec.Cmd) error {
+		args = cmd.Args
+		return nil
+	}).Build()
+	mockey.Mock(os.Rename).Return(nil).Build()
+
+	// testing
+	assert.Nil(t, FFmpeg().Tag("track.m4a", map[string]string{"title": "Title"}, "cover.jpg"))
+	assert.Equal(t, []string{
+		"ffmpeg", "-i", "track.m4a", "-i", "cover.jpg", "-map", "0:a", "-map", "1", "-disposition:v", "attached_pic",
+		"-codec", "copy", "-metadata", "title=Title", "-movflags", "use_metadata_tags",
+		"-y", "track.tag.m4a",
+	}, args)
+}
+
+func TestTagFFmpegFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&exec.Cmd{}, "Run")).Return(errors.New("ko")).Build()
+
+	// testing
+	assert.Error(t, FFmpeg().Tag("track.flac", nil, ""))
+}
+
+func TestTagRenameFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&exec.Cmd{}, "Run")).Return(nil).Build()
+	mockey.Mock(os.Rename).Return(errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, FFmpeg().Tag("track.flac", nil, ""), "ko")
+}
+
+func TestTags(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&exec.Cmd{}, "Run")).To(func(cmd *exec.Cmd) error {
+		return sys.ErrOnly(cmd.Stdout.Write([]byte(`{
+			"streams": [{"tags": {"SPOTIFY_ID": "stream", "TITLE": "Title"}}, {}],
+			"format": {"tags": {"spotify_id": "format"}}
+		}`)))
+	}).Build()
+
+	// testing
+	tags, err := FFmpeg().Tags("track.opus")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"spotify_id": "format", "title": "Title"}, tags)
+}
+
+func TestTagsFFprobeFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&exec.Cmd{}, "Run")).To(func(cmd *exec.Cmd) error {
+		sys.ErrSuppress(sys.ErrOnly(cmd.Stderr.Write([]byte("ko"))))
+		return errors.New("exit status 1")
+	}).Build()
+
+	// testing
+	assert.EqualError(t, sys.ErrOnly(FFmpeg().Tags("track.opus")), "ko")
+}
+
+func TestTagsUnmarshalFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(mockey.GetMethod(&exec.Cmd{}, "Run")).To(func(cmd *exec.Cmd) error {
+		return sys.ErrOnly(cmd.Stdout.Write([]byte("{")))
+	}).Build()
+
+	// testing
+	assert.Error(t, sys.ErrOnly(FFmpeg().Tags("track.opus")))
+}
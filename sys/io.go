@@ -46,3 +46,16 @@ func CacheDirectory() string {
 func CacheFile(filename string) string {
 	return filepath.Join(CacheDirectory(), filename)
 }
+
+func ProfilesDirectory() string {
+	return CacheFile("profiles")
+}
+
+// each named profile keeps its state in a dedicated folder,
+// while the default (unnamed) one uses the cache folder itself
+func ProfileCacheFile(profile, filename string) string {
+	if len(profile) == 0 {
+		return CacheFile(filename)
+	}
+	return filepath.Join(ProfilesDirectory(), profile, filename)
+}
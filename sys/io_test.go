@@ -145,3 +145,14 @@ func TestCacheFileFallback(t *testing.T) {
 	// testing
 	assert.Equal(t, "/tmp/spotitube/fname.txt", CacheFile("fname.txt"))
 }
+
+func TestProfileCacheFile(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(xdg.CacheFile).Return("/dir/spotitube", nil).Build()
+
+	// testing
+	assert.Equal(t, "/dir/spotitube/profiles", ProfilesDirectory())
+	assert.Equal(t, "/dir/spotitube/fname.txt", ProfileCacheFile("", "fname.txt"))
+	assert.Equal(t, "/dir/spotitube/profiles/alice/fname.txt", ProfileCacheFile("alice", "fname.txt"))
+}
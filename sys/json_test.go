@@ -0,0 +1,54 @@
+package sys
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bytedance/mockey"
+	"github.com/stretchr/testify/assert"
+)
+
+func BenchmarkJSON(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		TestJSON(&testing.T{})
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var (
+		path  = filepath.Join(t.TempDir(), "folder", "state.json")
+		value = map[string]int{"key": 1}
+	)
+
+	// testing
+	assert.Nil(t, JSONLoad(path, &value))
+	assert.Equal(t, map[string]int{"key": 1}, value)
+	assert.Nil(t, JSONSave(path, map[string]int{"key": 2}))
+	assert.Nil(t, JSONLoad(path, &value))
+	assert.Equal(t, map[string]int{"key": 2}, value)
+}
+
+func TestJSONLoadFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(os.ReadFile).Return(nil, errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, JSONLoad("state.json", &struct{}{}), "ko")
+}
+
+func TestJSONSaveFailure(t *testing.T) {
+	// monkey patching
+	defer mockey.UnPatchAll()
+	mockey.Mock(os.MkdirAll).Return(errors.New("ko")).Build()
+
+	// testing
+	assert.EqualError(t, JSONSave("state.json", struct{}{}), "ko")
+}
+
+func TestJSONSaveMarshalFailure(t *testing.T) {
+	// testing
+	assert.Error(t, JSONSave(filepath.Join(t.TempDir(), "state.json"), make(chan int)))
+}